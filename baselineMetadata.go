@@ -0,0 +1,263 @@
+package providertest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pulumi/providertest/cachefile"
+	"github.com/pulumi/providertest/optproviderupgrade"
+	"github.com/pulumi/providertest/pulumitest"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+)
+
+// baselineMetadata describes how a cached upgrade baseline was recorded.
+// It's written to metadata.json alongside the cached stack state and gRPC log.
+type baselineMetadata struct {
+	// ProgramHash is a hash of the program source the baseline was recorded from.
+	ProgramHash string `json:"programHash"`
+	// ProviderName is the name of the provider the baseline was recorded with.
+	ProviderName string `json:"providerName"`
+	// BaselineVersion is the version of the provider the baseline was recorded with.
+	BaselineVersion string `json:"baselineVersion"`
+	// PulumiVersion is the version of the Pulumi CLI the baseline was recorded with.
+	PulumiVersion string `json:"pulumiVersion,omitempty"`
+	// RecordedAt is the time the baseline was recorded.
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+const baselineMetadataFile = "metadata.json"
+
+// baselineCacheFiles are all the files written to an upgrade cache directory when recording a baseline.
+var baselineCacheFiles = []string{
+	baselineStackFile, baselineStackFile + cachefile.GzipExtension,
+	baselineGrpcLogFile, baselineGrpcLogFile + cachefile.GzipExtension,
+	baselineMetadataFile,
+}
+
+// Directories which are created by installing dependencies or building the program and so aren't part of the source.
+var programHashIgnoredDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"venv":         true,
+	".venv":        true,
+	"__pycache__":  true,
+	"bin":          true,
+	"obj":          true,
+}
+
+// Lock files which are generated by installing dependencies.
+var programHashIgnoredFiles = map[string]bool{
+	"package-lock.json": true,
+	"yarn.lock":         true,
+	"pnpm-lock.yaml":    true,
+	"go.sum":            true,
+	"destroy.sh":        true,
+}
+
+// rerecordBaselines returns true if the PULUMITEST_RERECORD_BASELINES environment variable is set to "true".
+func rerecordBaselines() bool {
+	value, ok := os.LookupEnv("PULUMITEST_RERECORD_BASELINES")
+	return ok && strings.EqualFold(value, "true")
+}
+
+// rerecordedCacheDirs tracks the cache directories which have already been re-recorded by this process so that
+// PULUMITEST_RERECORD_BASELINES only causes each baseline to be recorded once.
+var rerecordedCacheDirs sync.Map
+
+func newBaselineMetadata(t pulumitest.PT, programDir, providerName, baselineVersion string) baselineMetadata {
+	t.Helper()
+	programHash, err := hashProgram(programDir)
+	if err != nil {
+		t.Log(fmt.Sprintf("failed to hash program %s: %v", programDir, err))
+		t.FailNow()
+	}
+	return baselineMetadata{
+		ProgramHash:     programHash,
+		ProviderName:    providerName,
+		BaselineVersion: baselineVersion,
+		PulumiVersion:   pulumiVersion(),
+	}
+}
+
+// checkBaselineCache compares the metadata of an existing baseline in the cache directory with the expected metadata.
+// Depending on the stale baseline action, a mismatch will be logged, fail the test, or cause the cached baseline to be
+// removed so it will be recorded again.
+func checkBaselineCache(t pulumitest.PT, cacheDir string, expected baselineMetadata, action optproviderupgrade.StaleBaselineAction) {
+	t.Helper()
+	if _, err := os.Stat(cachefile.Resolve(filepath.Join(cacheDir, baselineStackFile))); os.IsNotExist(err) {
+		return // Nothing recorded yet.
+	}
+
+	if rerecordBaselines() {
+		if _, alreadyRerecorded := rerecordedCacheDirs.LoadOrStore(cacheDir, true); !alreadyRerecorded {
+			t.Log(fmt.Sprintf("re-recording baseline at %s because PULUMITEST_RERECORD_BASELINES is set to 'true'", cacheDir))
+			removeBaselineCache(t, cacheDir)
+			return
+		}
+	}
+
+	recorded, err := readBaselineMetadata(cacheDir)
+	if err != nil {
+		t.Log(fmt.Sprintf("failed to read baseline metadata: %v", err))
+		t.FailNow()
+		return
+	}
+	if recorded == nil {
+		t.Log(fmt.Sprintf("no baseline metadata found in %s so it can't be checked for staleness. "+
+			"To re-record all baselines, set PULUMITEST_RERECORD_BASELINES=true", cacheDir))
+		return
+	}
+
+	if recorded.PulumiVersion != expected.PulumiVersion {
+		t.Log(fmt.Sprintf("baseline at %s was recorded with Pulumi CLI %s, now running %s",
+			cacheDir, recorded.PulumiVersion, expected.PulumiVersion))
+	}
+
+	mismatches := recorded.mismatches(expected)
+	if len(mismatches) == 0 {
+		return
+	}
+	message := fmt.Sprintf("baseline at %s (recorded %s) is stale: %s", cacheDir,
+		recorded.RecordedAt.Format(time.RFC3339), strings.Join(mismatches, "; "))
+	switch action {
+	case optproviderupgrade.StaleBaselineFail:
+		t.Log(message + ". To re-record all baselines, set PULUMITEST_RERECORD_BASELINES=true")
+		t.FailNow()
+	case optproviderupgrade.StaleBaselineRerecord:
+		t.Log(message + ", re-recording")
+		removeBaselineCache(t, cacheDir)
+	default:
+		t.Log("WARNING: " + message + ". To re-record all baselines, set PULUMITEST_RERECORD_BASELINES=true")
+	}
+}
+
+// mismatches returns a description of each way in which the recorded metadata differs from the expected metadata.
+// The Pulumi CLI version and recording time are informational and not considered a mismatch.
+func (recorded baselineMetadata) mismatches(expected baselineMetadata) []string {
+	var mismatches []string
+	if recorded.ProgramHash != expected.ProgramHash {
+		mismatches = append(mismatches, "program source has changed")
+	}
+	if recorded.ProviderName != expected.ProviderName {
+		mismatches = append(mismatches, fmt.Sprintf("recorded with provider %q, expected %q",
+			recorded.ProviderName, expected.ProviderName))
+	}
+	if recorded.BaselineVersion != expected.BaselineVersion {
+		mismatches = append(mismatches, fmt.Sprintf("recorded with provider version %q, expected %q",
+			recorded.BaselineVersion, expected.BaselineVersion))
+	}
+	return mismatches
+}
+
+func removeBaselineCache(t pulumitest.PT, cacheDir string) {
+	t.Helper()
+	for _, file := range baselineCacheFiles {
+		if err := os.Remove(filepath.Join(cacheDir, file)); err != nil && !os.IsNotExist(err) {
+			t.Log(fmt.Sprintf("failed to remove stale baseline: %v", err))
+			t.FailNow()
+		}
+	}
+}
+
+// readBaselineMetadata reads the baseline metadata from the cache directory.
+// If the metadata file does not exist, returns nil, nil.
+func readBaselineMetadata(cacheDir string) (*baselineMetadata, error) {
+	path := filepath.Join(cacheDir, baselineMetadataFile)
+	metadataBytes, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var metadata baselineMetadata
+	if err := json.Unmarshal(metadataBytes, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal baseline metadata at %s: %w", path, err)
+	}
+	return &metadata, nil
+}
+
+// writeBaselineMetadata writes the baseline metadata to the cache directory, creating any directories needed.
+func writeBaselineMetadata(cacheDir string, metadata baselineMetadata) error {
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return err
+	}
+	metadataBytes, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(cacheDir, baselineMetadataFile), metadataBytes, 0644)
+}
+
+// hashProgram returns a SHA-256 hash of the program's source files, ignoring dependency directories and lock files.
+// Files are hashed in a deterministic order and include their path relative to the program directory.
+func hashProgram(dir string) (string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != dir && programHashIgnoredDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() || programHashIgnoredFiles[info.Name()] || isStackConfigFile(info.Name()) {
+			return nil
+		}
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+
+	hash := sha256.New()
+	for _, file := range files {
+		relPath, err := filepath.Rel(dir, file)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "%s\x00", filepath.ToSlash(relPath))
+		f, err := os.Open(file)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(hash, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// isStackConfigFile returns true for stack config files such as Pulumi.test.yaml. These are written when a stack is
+// created, so contain a random stack name and encryption salt which would change the hash on every run.
+func isStackConfigFile(name string) bool {
+	if name == "Pulumi.yaml" || name == "Pulumi.yml" {
+		return false
+	}
+	return strings.HasPrefix(name, "Pulumi.") && (strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml"))
+}
+
+// pulumiVersion returns the version of the Pulumi CLI on the PATH, or an empty string if it can't be determined.
+func pulumiVersion() string {
+	cmd, err := auto.NewPulumiCommand(nil)
+	if err != nil {
+		return ""
+	}
+	return cmd.Version().String()
+}
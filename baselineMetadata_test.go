@@ -0,0 +1,141 @@
+package providertest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pulumi/providertest/optproviderupgrade"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashProgram(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Pulumi.yaml"), []byte("name: test"), 0644))
+
+	original, err := hashProgram(dir)
+	require.NoError(t, err)
+
+	t.Run("ignores dependencies", func(t *testing.T) {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "node_modules", "pkg"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "node_modules", "pkg", "index.js"), []byte("x"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "package-lock.json"), []byte("{}"), 0644))
+		hash, err := hashProgram(dir)
+		require.NoError(t, err)
+		assert.Equal(t, original, hash)
+	})
+
+	t.Run("ignores stack config", func(t *testing.T) {
+		stackConfig := "encryptionsalt: v1:abc\nconfig:\n  test:key: value\n"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "Pulumi.p-it-host-yaml-1a2b3c.yaml"), []byte(stackConfig), 0644))
+		hash, err := hashProgram(dir)
+		require.NoError(t, err)
+		assert.Equal(t, original, hash)
+	})
+
+	t.Run("detects source changes", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "Pulumi.yaml"), []byte("name: changed"), 0644))
+		hash, err := hashProgram(dir)
+		require.NoError(t, err)
+		assert.NotEqual(t, original, hash)
+	})
+}
+
+func TestCheckBaselineCache(t *testing.T) {
+	t.Parallel()
+	recorded := baselineMetadata{
+		ProgramHash:     "abc",
+		ProviderName:    "random",
+		BaselineVersion: "4.5.0",
+		PulumiVersion:   "3.100.0",
+		RecordedAt:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	stale := recorded
+	stale.ProgramHash = "def"
+
+	writeCache := func(t *testing.T) string {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "stack.json"), []byte("{}"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "grpc.json"), []byte(""), 0644))
+		require.NoError(t, writeBaselineMetadata(dir, recorded))
+		return dir
+	}
+
+	t.Run("matching", func(t *testing.T) {
+		t.Parallel()
+		dir := writeCache(t)
+		tt := &mockT{T: t}
+		checkBaselineCache(tt, dir, recorded, optproviderupgrade.StaleBaselineFail)
+		assert.False(t, tt.failed)
+		assert.FileExists(t, filepath.Join(dir, "stack.json"))
+	})
+
+	t.Run("ignores pulumi version", func(t *testing.T) {
+		t.Parallel()
+		dir := writeCache(t)
+		tt := &mockT{T: t}
+		newerCli := recorded
+		newerCli.PulumiVersion = "3.200.0"
+		checkBaselineCache(tt, dir, newerCli, optproviderupgrade.StaleBaselineFail)
+		assert.False(t, tt.failed)
+	})
+
+	t.Run("warn", func(t *testing.T) {
+		t.Parallel()
+		dir := writeCache(t)
+		tt := &mockT{T: t}
+		checkBaselineCache(tt, dir, stale, optproviderupgrade.StaleBaselineWarn)
+		assert.False(t, tt.failed)
+		assert.FileExists(t, filepath.Join(dir, "stack.json"))
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		t.Parallel()
+		dir := writeCache(t)
+		tt := &mockT{T: t}
+		checkBaselineCache(tt, dir, stale, optproviderupgrade.StaleBaselineFail)
+		assert.True(t, tt.failed)
+		assert.FileExists(t, filepath.Join(dir, "stack.json"))
+	})
+
+	t.Run("rerecord", func(t *testing.T) {
+		t.Parallel()
+		dir := writeCache(t)
+		tt := &mockT{T: t}
+		checkBaselineCache(tt, dir, stale, optproviderupgrade.StaleBaselineRerecord)
+		assert.False(t, tt.failed)
+		assert.NoFileExists(t, filepath.Join(dir, "stack.json"))
+		assert.NoFileExists(t, filepath.Join(dir, "grpc.json"))
+		assert.NoFileExists(t, filepath.Join(dir, baselineMetadataFile))
+	})
+
+	t.Run("missing metadata", func(t *testing.T) {
+		t.Parallel()
+		dir := writeCache(t)
+		require.NoError(t, os.Remove(filepath.Join(dir, baselineMetadataFile)))
+		tt := &mockT{T: t}
+		checkBaselineCache(tt, dir, stale, optproviderupgrade.StaleBaselineFail)
+		assert.False(t, tt.failed)
+		assert.FileExists(t, filepath.Join(dir, "stack.json"))
+	})
+}
+
+type mockT struct {
+	*testing.T
+	failed bool
+}
+
+func (m *mockT) Fail() {
+	m.failed = true
+}
+
+func (m *mockT) FailNow() {
+	m.failed = true
+}
+
+func (m *mockT) Failed() bool {
+	return m.failed
+}
@@ -0,0 +1,83 @@
+// Package cachefile reads and writes cached test artifacts, such as stack exports and gRPC logs, which may optionally
+// be gzip-compressed.
+package cachefile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GzipExtension is the file extension used for compressed cache files.
+const GzipExtension = ".gz"
+
+// IsCompressed returns true if the path has the gzip extension, in which case WriteFile will compress the contents.
+func IsCompressed(path string) bool {
+	return strings.HasSuffix(path, GzipExtension)
+}
+
+// Resolve returns the path of an existing cache file, checking for both the compressed and uncompressed variants of
+// the given path. If neither exists, the path is returned unchanged.
+func Resolve(path string) string {
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	alternative := path + GzipExtension
+	if IsCompressed(path) {
+		alternative = strings.TrimSuffix(path, GzipExtension)
+	}
+	if _, err := os.Stat(alternative); err == nil {
+		return alternative
+	}
+	return path
+}
+
+// ReadFile reads a cache file, decompressing it if it's gzip-compressed.
+// If the path doesn't exist, the compressed or uncompressed variant of the path is read instead.
+func ReadFile(path string) ([]byte, error) {
+	contents, err := os.ReadFile(Resolve(path))
+	if err != nil {
+		return nil, err
+	}
+	return Decompress(contents)
+}
+
+// Decompress returns the decompressed contents if they are gzip-compressed, otherwise returns the contents unchanged.
+func Decompress(contents []byte) ([]byte, error) {
+	if !isGzip(contents) {
+		return contents, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(contents))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// WriteFile writes a cache file, creating any directories needed.
+// If the path has the gzip extension, the contents are compressed.
+func WriteFile(path string, contents []byte) error {
+	if IsCompressed(path) {
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		if _, err := writer.Write(contents); err != nil {
+			return err
+		}
+		if err := writer.Close(); err != nil {
+			return err
+		}
+		contents = buf.Bytes()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, contents, 0644)
+}
+
+func isGzip(contents []byte) bool {
+	return len(contents) >= 2 && contents[0] == 0x1f && contents[1] == 0x8b
+}
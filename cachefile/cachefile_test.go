@@ -0,0 +1,47 @@
+package cachefile_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/providertest/cachefile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressedRoundTrip(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nested", "stack.json.gz")
+	contents := []byte(`{"deployment":{}}`)
+
+	require.NoError(t, cachefile.WriteFile(path, contents))
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotEqual(t, contents, raw, "expected file to be compressed")
+
+	read, err := cachefile.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, contents, read)
+}
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "stack.json")
+	compressed := filepath.Join(dir, "grpc.json.gz")
+	require.NoError(t, cachefile.WriteFile(plain, []byte("{}")))
+	require.NoError(t, cachefile.WriteFile(compressed, []byte("{}")))
+
+	assert.Equal(t, plain, cachefile.Resolve(plain))
+	assert.Equal(t, plain, cachefile.Resolve(plain+".gz"))
+	assert.Equal(t, compressed, cachefile.Resolve(filepath.Join(dir, "grpc.json")))
+	missing := filepath.Join(dir, "missing.json")
+	assert.Equal(t, missing, cachefile.Resolve(missing))
+
+	read, err := cachefile.ReadFile(filepath.Join(dir, "grpc.json"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("{}"), read)
+}
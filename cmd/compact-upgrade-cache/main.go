@@ -0,0 +1,33 @@
+// Command compact-upgrade-cache rewrites recorded upgrade test baselines in place, removing gRPC log entries which
+// are never replayed and optionally gzip-compressing the cached files.
+//
+// Usage:
+//
+//	go run github.com/pulumi/providertest/cmd/compact-upgrade-cache [-compress] [dir ...]
+//
+// If no directories are given, "testdata/recorded" is used.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pulumi/providertest"
+)
+
+func main() {
+	compress := flag.Bool("compress", false, "gzip-compress the cached stack state and gRPC logs")
+	flag.Parse()
+
+	dirs := flag.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"testdata/recorded"}
+	}
+	for _, dir := range dirs {
+		if err := providertest.CompactUpgradeCache(dir, *compress); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to compact %s: %v\n", dir, err)
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Command sweep-leaked-stacks destroys stacks which tests failed to destroy, as recorded in leak manifests, and
+// removes the temporary directories of those tests, including retained temporary directories left without a manifest.
+//
+// Usage:
+//
+//	go run github.com/pulumi/providertest/cmd/sweep-leaked-stacks [-dir dir] [-temp-dir dir] [-min-age duration]
+//
+// If no directory is given, PULUMITEST_LEAK_MANIFEST_DIR or the default manifest directory is used. If no temp
+// directory is given, PULUMITEST_TEMP_DIR or the OS temporary directory is searched for retained temp directories.
+// Stacks which were tested with attached providers can't be destroyed by this command; call
+// pulumitest.SweepLeakedStacks with the provider factories instead.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pulumi/providertest/pulumitest"
+)
+
+func main() {
+	dir := flag.String("dir", pulumitest.LeakManifestDir(), "directory containing leak manifests")
+	tempDir := flag.String("temp-dir", "", "directory containing the tests' temp directories")
+	minAge := flag.Duration("min-age", 0, "only sweep stacks leaked at least this long ago")
+	flag.Parse()
+
+	results, err := pulumitest.SweepLeakedStacks(context.Background(), pulumitest.SweepOptions{
+		ManifestDir: *dir,
+		MinAge:      *minAge,
+		TempDir:     *tempDir,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to sweep %s: %v\n", *dir, err)
+		os.Exit(1)
+	}
+	failed := false
+	for _, result := range results {
+		if result.ManifestPath == "" && result.Manifest.TempDir != "" {
+			failed = failed || result.Status == pulumitest.SweepFailed
+			if result.Err != nil {
+				fmt.Printf("%s: %s: %v\n", result.Status, result.Manifest.TempDir, result.Err)
+				continue
+			}
+			fmt.Printf("%s: %s\n", result.Status, result.Manifest.TempDir)
+			continue
+		}
+		if result.Err != nil {
+			failed = failed || result.Status == pulumitest.SweepFailed
+			fmt.Printf("%s: %s (%s): %v\n", result.Status, result.Manifest.StackName, result.ManifestPath, result.Err)
+			continue
+		}
+		fmt.Printf("%s: %s (%s)\n", result.Status, result.Manifest.StackName, result.ManifestPath)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -2,10 +2,9 @@ package grpclog
 
 import (
 	"encoding/json"
-	"os"
-	"path/filepath"
 	"strings"
 
+	"github.com/pulumi/providertest/cachefile"
 	"github.com/pulumi/providertest/pulumitest/sanitize"
 	rpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
 	jsonpb "google.golang.org/protobuf/encoding/protojson"
@@ -163,8 +162,11 @@ func unmarshalTypedEntry[TRequest, TResponse any](entry GrpcLogEntry) (*TypedEnt
 	return &typedEntry, nil
 }
 
+// LoadLog reads a log from the given path.
+// Gzip-compressed logs are decompressed, and if the path doesn't exist, the path with or without the ".gz" extension
+// is read instead.
 func LoadLog(path string) (*GrpcLog, error) {
-	file, err := os.ReadFile(path)
+	file, err := cachefile.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
@@ -214,19 +216,35 @@ func (e *GrpcLogEntry) SanitizeSecrets() {
 	e.Response = sanitize.SanitizeSecretsInGrpcLog(e.Response)
 }
 
+// Compact removes entries which are never needed to replay the log: GetSchema calls, which contain the full provider
+// schema, and repeated GetPluginInfo calls which are identical to an earlier call.
+func (l *GrpcLog) Compact() {
+	compacted := make([]GrpcLogEntry, 0, len(l.Entries))
+	seenPluginInfo := map[string]bool{}
+	for _, entry := range l.Entries {
+		if entry.Method == string(GetSchema) {
+			continue
+		}
+		if strings.HasSuffix(entry.Method, "/GetPluginInfo") {
+			key := entry.Method + "\n" + string(entry.Request) + "\n" + string(entry.Response)
+			if seenPluginInfo[key] {
+				continue
+			}
+			seenPluginInfo[key] = true
+		}
+		compacted = append(compacted, entry)
+	}
+	l.Entries = compacted
+}
+
 // WriteTo writes the log to the given path.
-// Creates any directories needed.
+// Creates any directories needed. If the path ends with ".gz", the log is gzip-compressed.
 func (l *GrpcLog) WriteTo(path string) error {
 	bytes, err := l.Marshal()
 	if err != nil {
 		return err
 	}
-	dir := filepath.Dir(path)
-	err = os.MkdirAll(dir, 0755)
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(path, bytes, 0644)
+	return cachefile.WriteFile(path, bytes)
 }
 
 func (l *GrpcLog) Marshal() ([]byte, error) {
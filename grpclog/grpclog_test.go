@@ -49,3 +49,41 @@ func TestFindByURN(t *testing.T) {
 	assert.Nil(t, resource)
 	assert.Equal(t, -1, i)
 }
+
+func TestCompact(t *testing.T) {
+	t.Parallel()
+	log, err := grpclog.LoadLog(filepath.Join("testdata", "aws_bucket_grpc.json"))
+	assert.NoError(t, err)
+	log.Entries = append(log.Entries, grpclog.GrpcLogEntry{
+		Method:   string(grpclog.GetSchema),
+		Request:  []byte(`{"version":0}`),
+		Response: []byte(`{"schema":"{}"}`),
+	})
+	creates := log.WhereMethod(grpclog.Create)
+
+	log.Compact()
+
+	assert.Empty(t, log.WhereMethod(grpclog.GetSchema))
+	assert.Equal(t, 1, len(log.WhereMethod(grpclog.GetPluginInfo)))
+	assert.Equal(t, creates, log.WhereMethod(grpclog.Create))
+}
+
+func TestCompressedLog(t *testing.T) {
+	t.Parallel()
+	log, err := grpclog.LoadLog(filepath.Join("testdata", "aws_bucket_grpc.json"))
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	compressedPath := filepath.Join(dir, "grpc.json.gz")
+	err = log.WriteTo(compressedPath)
+	assert.NoError(t, err)
+
+	loaded, err := grpclog.LoadLog(compressedPath)
+	assert.NoError(t, err)
+	assert.Equal(t, log, loaded)
+
+	// The compressed log is found when loading the uncompressed path.
+	loaded, err = grpclog.LoadLog(filepath.Join(dir, "grpc.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, log, loaded)
+}
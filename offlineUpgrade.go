@@ -0,0 +1,270 @@
+package providertest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/providertest/grpclog"
+	"github.com/pulumi/providertest/providers"
+	"github.com/pulumi/providertest/pulumitest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// OfflineUpgradeReport lists the result of diffing each recorded resource against the new provider.
+type OfflineUpgradeReport struct {
+	// Resources are sorted by URN.
+	Resources []OfflineResourceDiff `json:"resources"`
+}
+
+// OfflineResourceDiff is the new provider's Diff response for a single recorded resource.
+type OfflineResourceDiff struct {
+	URN                 string   `json:"urn"`
+	Type                string   `json:"type"`
+	ID                  string   `json:"id"`
+	Changes             bool     `json:"changes"`
+	Diffs               []string `json:"diffs,omitempty"`
+	Replaces            []string `json:"replaces,omitempty"`
+	DeleteBeforeReplace bool     `json:"deleteBeforeReplace,omitempty"`
+	// CheckFailures are the failures returned by the new provider when checking the recorded inputs.
+	CheckFailures []string `json:"checkFailures,omitempty"`
+}
+
+// CheckProviderUpgradeOffline replays the Configure and Check requests recorded in a baseline grpc.json against a
+// new in-process provider, then diffs the recorded state of each resource against the newly checked inputs.
+// This doesn't require the Pulumi CLI or a running engine so is much faster than a full upgrade preview, but it only
+// covers resources created by the named provider and doesn't account for program changes between versions.
+//
+// The grpc.json path for a recorded baseline can be found using GetUpgradeCacheDir.
+func CheckProviderUpgradeOffline(t pulumitest.PT, providerName string, factory providers.ResourceProviderServerFactory, grpcLogPath string) OfflineUpgradeReport {
+	t.Helper()
+	log, err := grpclog.LoadLog(grpcLogPath)
+	if err != nil {
+		t.Log(fmt.Sprintf("failed to load baseline gRPC log: %v", err))
+		t.FailNow()
+		return OfflineUpgradeReport{}
+	}
+	server, err := factory(noProgramProviderContext{})
+	if err != nil {
+		t.Log(fmt.Sprintf("failed to start provider: %v", err))
+		t.FailNow()
+		return OfflineUpgradeReport{}
+	}
+	report, err := DiffRecordedResources(pulumitest.TestContext(t), server, log, providerName)
+	if err != nil {
+		t.Log(fmt.Sprintf("failed to diff recorded resources: %v", err))
+		t.FailNow()
+		return OfflineUpgradeReport{}
+	}
+	return *report
+}
+
+// DiffRecordedResources configures the server using the recorded Configure request for the provider, then checks
+// and diffs each resource created by the provider in the log.
+//
+// Where the log contains multiple Configure requests, the first containing configuration for the provider is used,
+// falling back to the first Configure request.
+func DiffRecordedResources(ctx context.Context, server pulumirpc.ResourceProviderServer, log *grpclog.GrpcLog, providerName string) (*OfflineUpgradeReport, error) {
+	configures, err := log.Configures()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recorded Configure requests: %w", err)
+	}
+	if configure := findConfigure(configures, providerName); configure != nil {
+		if _, err := server.Configure(ctx, configure); err != nil {
+			return nil, fmt.Errorf("failed to configure provider: %w", err)
+		}
+	}
+
+	checks, err := log.Checks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recorded Check requests: %w", err)
+	}
+	states, err := recordedStates(log)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &OfflineUpgradeReport{Resources: []OfflineResourceDiff{}}
+	for urn, state := range states {
+		if resourcePackage(urn) != providerName {
+			continue
+		}
+		check := lastCheck(checks, urn)
+		if check == nil {
+			continue
+		}
+		diff, err := diffRecordedResource(ctx, server, &check.Request, check.Response.GetInputs(), state)
+		if err != nil {
+			return nil, fmt.Errorf("failed to diff %s: %w", urn, err)
+		}
+		report.Resources = append(report.Resources, *diff)
+	}
+	sort.Slice(report.Resources, func(i, j int) bool {
+		return report.Resources[i].URN < report.Resources[j].URN
+	})
+	return report, nil
+}
+
+func findConfigure(configures []grpclog.TypedEntry[pulumirpc.ConfigureRequest, pulumirpc.ConfigureResponse], providerName string) *pulumirpc.ConfigureRequest {
+	if len(configures) == 0 {
+		return nil
+	}
+	for i := range configures {
+		for key := range configures[i].Request.GetVariables() {
+			if strings.HasPrefix(key, providerName+":") {
+				return &configures[i].Request
+			}
+		}
+	}
+	return &configures[0].Request
+}
+
+// lastCheck returns the last recorded Check for the URN, or nil if none is found.
+func lastCheck(checks []grpclog.TypedEntry[pulumirpc.CheckRequest, pulumirpc.CheckResponse], urn string) *grpclog.TypedEntry[pulumirpc.CheckRequest, pulumirpc.CheckResponse] {
+	for i := len(checks) - 1; i >= 0; i-- {
+		if checks[i].Request.GetUrn() == urn {
+			return &checks[i]
+		}
+	}
+	return nil
+}
+
+// recordedState is the ID and output properties of a resource after its last Create or Update.
+type recordedState struct {
+	id         string
+	properties *structpb.Struct
+}
+
+func recordedStates(log *grpclog.GrpcLog) (map[string]recordedState, error) {
+	states := map[string]recordedState{}
+	creates, err := log.Creates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recorded Create requests: %w", err)
+	}
+	for i := range creates {
+		create := &creates[i]
+		if create.Request.GetPreview() || create.Response.GetId() == "" {
+			continue
+		}
+		states[create.Request.GetUrn()] = recordedState{
+			id:         create.Response.GetId(),
+			properties: create.Response.GetProperties(),
+		}
+	}
+	updates, err := log.Updates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recorded Update requests: %w", err)
+	}
+	for i := range updates {
+		update := &updates[i]
+		if update.Request.GetPreview() {
+			continue
+		}
+		states[update.Request.GetUrn()] = recordedState{
+			id:         update.Request.GetId(),
+			properties: update.Response.GetProperties(),
+		}
+	}
+	return states, nil
+}
+
+func diffRecordedResource(ctx context.Context, server pulumirpc.ResourceProviderServer, recordedCheck *pulumirpc.CheckRequest, oldInputs *structpb.Struct, state recordedState) (*OfflineResourceDiff, error) {
+	urn := recordedCheck.GetUrn()
+	result := &OfflineResourceDiff{
+		URN:  urn,
+		Type: string(resource.URN(urn).Type()),
+		ID:   state.id,
+	}
+
+	checkRequest := &pulumirpc.CheckRequest{
+		Urn:        urn,
+		Name:       recordedCheck.GetName(),
+		Type:       recordedCheck.GetType(),
+		Olds:       oldInputs,
+		News:       recordedCheck.GetNews(),
+		RandomSeed: recordedCheck.GetRandomSeed(),
+	}
+	checkResponse, err := server.Check(ctx, checkRequest)
+	if err != nil {
+		return nil, err
+	}
+	for _, failure := range checkResponse.GetFailures() {
+		result.CheckFailures = append(result.CheckFailures, fmt.Sprintf("%s: %s", failure.GetProperty(), failure.GetReason()))
+	}
+	if len(result.CheckFailures) > 0 {
+		return result, nil
+	}
+
+	diffResponse, err := server.Diff(ctx, &pulumirpc.DiffRequest{
+		Id:        state.id,
+		Urn:       urn,
+		Name:      recordedCheck.GetName(),
+		Type:      recordedCheck.GetType(),
+		Olds:      state.properties,
+		News:      checkResponse.GetInputs(),
+		OldInputs: oldInputs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Changes = diffResponse.GetChanges() == pulumirpc.DiffResponse_DIFF_SOME || len(diffResponse.GetReplaces()) > 0
+	result.Diffs = diffResponse.GetDiffs()
+	result.Replaces = diffResponse.GetReplaces()
+	result.DeleteBeforeReplace = diffResponse.GetDeleteBeforeReplace()
+	return result, nil
+}
+
+// resourcePackage returns the package of the resource type in the URN, such as "aws" for "aws:s3/bucket:Bucket".
+func resourcePackage(urn string) string {
+	resourceType := string(resource.URN(urn).Type())
+	pkg, _, _ := strings.Cut(resourceType, ":")
+	return pkg
+}
+
+// Changed returns a report containing only the resources with changes or check failures.
+func (r OfflineUpgradeReport) Changed() OfflineUpgradeReport {
+	changed := OfflineUpgradeReport{Resources: []OfflineResourceDiff{}}
+	for _, res := range r.Resources {
+		if res.Changes || len(res.CheckFailures) > 0 {
+			changed.Resources = append(changed.Resources, res)
+		}
+	}
+	return changed
+}
+
+// WithReplacements returns the URNs of resources which the new provider would replace.
+func (r OfflineUpgradeReport) WithReplacements() []string {
+	urns := []string{}
+	for _, res := range r.Resources {
+		if len(res.Replaces) > 0 {
+			urns = append(urns, res.URN)
+		}
+	}
+	return urns
+}
+
+// String renders the changed resources for test output.
+func (r OfflineUpgradeReport) String() string {
+	changed := r.Changed()
+	if len(changed.Resources) == 0 {
+		return "no changes"
+	}
+	var sb strings.Builder
+	for i, res := range changed.Resources {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		switch {
+		case len(res.CheckFailures) > 0:
+			fmt.Fprintf(&sb, "%s (check failed: %s)", res.URN, strings.Join(res.CheckFailures, "; "))
+		case len(res.Replaces) > 0:
+			fmt.Fprintf(&sb, "%s (replace: %s)", res.URN, strings.Join(res.Replaces, ", "))
+		default:
+			fmt.Fprintf(&sb, "%s (update: %s)", res.URN, strings.Join(res.Diffs, ", "))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,60 @@
+package providertest_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/providertest"
+	"github.com/pulumi/providertest/providers"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckProviderUpgradeOffline(t *testing.T) {
+	t.Parallel()
+	const bucketURN = "urn:pulumi:p-it-antons-mac-bucket-9f59db4a::test::aws:s3/bucket:Bucket::tested-resource"
+	grpcLogPath := filepath.Join("grpclog", "testdata", "aws_bucket_grpc.json")
+
+	factory := func(diff func(*pulumirpc.DiffRequest) *pulumirpc.DiffResponse) providers.ResourceProviderServerFactory {
+		return func(_ providers.PulumiTest) (pulumirpc.ResourceProviderServer, error) {
+			return providers.NewProviderMock(providers.ProviderMocks{
+				Diff: func(ctx context.Context, in *pulumirpc.DiffRequest) (*pulumirpc.DiffResponse, error) {
+					return diff(in), nil
+				},
+			})
+		}
+	}
+
+	t.Run("no changes", func(t *testing.T) {
+		var diffRequest *pulumirpc.DiffRequest
+		report := providertest.CheckProviderUpgradeOffline(t, "aws", factory(func(in *pulumirpc.DiffRequest) *pulumirpc.DiffResponse {
+			diffRequest = in
+			return &pulumirpc.DiffResponse{Changes: pulumirpc.DiffResponse_DIFF_NONE}
+		}), grpcLogPath)
+
+		require.Len(t, report.Resources, 1)
+		assert.Equal(t, bucketURN, report.Resources[0].URN)
+		assert.Equal(t, "aws:s3/bucket:Bucket", report.Resources[0].Type)
+		assert.Empty(t, report.Changed().Resources)
+		assert.Equal(t, "no changes", report.String())
+
+		require.NotNil(t, diffRequest)
+		assert.Equal(t, report.Resources[0].ID, diffRequest.GetId())
+		assert.Equal(t, "private", diffRequest.GetOlds().GetFields()["acl"].GetStringValue())
+	})
+
+	t.Run("replacement", func(t *testing.T) {
+		report := providertest.CheckProviderUpgradeOffline(t, "aws", factory(func(in *pulumirpc.DiffRequest) *pulumirpc.DiffResponse {
+			return &pulumirpc.DiffResponse{
+				Changes:  pulumirpc.DiffResponse_DIFF_SOME,
+				Diffs:    []string{"bucket"},
+				Replaces: []string{"bucket"},
+			}
+		}), grpcLogPath)
+
+		assert.Equal(t, []string{bucketURN}, report.WithReplacements())
+		assert.Equal(t, bucketURN+" (replace: bucket)", report.String())
+	})
+}
@@ -1,6 +1,7 @@
 package optproviderupgrade
 
 import (
+	"github.com/pulumi/providertest/providers"
 	"github.com/pulumi/providertest/pulumitest/opttest"
 )
 
@@ -48,11 +49,73 @@ func NewSourcePath(path string) PreviewProviderUpgradeOpt {
 	})
 }
 
+// NewProvider sets the provider to attach for the new version of the named provider, replacing any provider
+// configured for that name on the original test. The baseline still uses the downloaded baseline version.
+func NewProvider(name string, factory providers.ProviderFactory) PreviewProviderUpgradeOpt {
+	return optionFunc(func(o *PreviewProviderUpgradeOptions) {
+		if o.NewProviders == nil {
+			o.NewProviders = map[string]providers.ProviderFactory{}
+		}
+		o.NewProviders[name] = factory
+	})
+}
+
+// CompressCache gzip-compresses the stack state and gRPC log when recording a baseline, writing them as
+// "stack.json.gz" and "grpc.json.gz". Existing uncompressed baselines continue to be used until they're re-recorded.
+func CompressCache() PreviewProviderUpgradeOpt {
+	return optionFunc(func(o *PreviewProviderUpgradeOptions) {
+		o.CompressCache = true
+	})
+}
+
+// CompactCache omits gRPC log entries which are never replayed, such as GetSchema responses and repeated
+// GetPluginInfo calls, when recording a baseline.
+func CompactCache() PreviewProviderUpgradeOpt {
+	return optionFunc(func(o *PreviewProviderUpgradeOptions) {
+		o.CompactCache = true
+	})
+}
+
+// CurrentProviderVersion sets the version of the provider under test for PreviewProviderDowngrade. It's recorded in
+// the baseline metadata so a cached baseline written by a different version of the provider is detected as stale.
+func CurrentProviderVersion(version string) PreviewProviderUpgradeOpt {
+	return optionFunc(func(o *PreviewProviderUpgradeOptions) {
+		o.CurrentProviderVersion = version
+	})
+}
+
+// StaleBaselineAction is the action to take when a cached baseline no longer matches the program or provider version.
+type StaleBaselineAction string
+
+const (
+	// StaleBaselineWarn logs that the baseline is stale but continues to use it.
+	StaleBaselineWarn StaleBaselineAction = "warn"
+	// StaleBaselineFail fails the test when the baseline is stale.
+	StaleBaselineFail StaleBaselineAction = "fail"
+	// StaleBaselineRerecord discards the stale baseline and records a new one.
+	StaleBaselineRerecord StaleBaselineAction = "rerecord"
+)
+
+// OnStaleBaseline sets the action to take when the cached baseline was recorded from a different program or provider
+// version than the current one. Defaults to StaleBaselineWarn.
+// Setting the PULUMITEST_RERECORD_BASELINES environment variable to "true" re-records all baselines regardless of
+// this option.
+func OnStaleBaseline(action StaleBaselineAction) PreviewProviderUpgradeOpt {
+	return optionFunc(func(o *PreviewProviderUpgradeOptions) {
+		o.StaleBaselineAction = action
+	})
+}
+
 type PreviewProviderUpgradeOptions struct {
-	CacheDirTemplate []string
-	DisableAttach    bool
-	BaselineOpts     []opttest.Option
-	NewSourcePath    string
+	CacheDirTemplate       []string
+	DisableAttach          bool
+	BaselineOpts           []opttest.Option
+	NewSourcePath          string
+	StaleBaselineAction    StaleBaselineAction
+	NewProviders           map[string]providers.ProviderFactory
+	CompressCache          bool
+	CompactCache           bool
+	CurrentProviderVersion string
 }
 
 type PreviewProviderUpgradeOpt interface {
@@ -61,10 +124,20 @@ type PreviewProviderUpgradeOpt interface {
 
 func Defaults() PreviewProviderUpgradeOptions {
 	return PreviewProviderUpgradeOptions{
-		CacheDirTemplate: []string{"testdata", "recorded", "TestProviderUpgrade", ProgramName, BaselineVersion},
+		CacheDirTemplate:    []string{"testdata", "recorded", "TestProviderUpgrade", ProgramName, BaselineVersion},
+		StaleBaselineAction: StaleBaselineWarn,
 	}
 }
 
+// DowngradeDefaults returns the default options for a provider downgrade test.
+// This is the same as Defaults, but with a cache directory of
+// "testdata/recorded/TestProviderDowngrade/{programName}/{baselineVersion}".
+func DowngradeDefaults() PreviewProviderUpgradeOptions {
+	options := Defaults()
+	options.CacheDirTemplate = []string{"testdata", "recorded", "TestProviderDowngrade", ProgramName, BaselineVersion}
+	return options
+}
+
 type optionFunc func(*PreviewProviderUpgradeOptions)
 
 func (o optionFunc) Apply(opts *PreviewProviderUpgradeOptions) {
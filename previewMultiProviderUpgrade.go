@@ -0,0 +1,49 @@
+package providertest
+
+import (
+	"strings"
+
+	"github.com/pulumi/providertest/optproviderupgrade"
+	"github.com/pulumi/providertest/pulumitest"
+	"github.com/pulumi/providertest/pulumitest/optnewstack"
+	"github.com/pulumi/providertest/pulumitest/opttest"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optpreview"
+)
+
+// PreviewMultiProviderUpgrade captures the state of a stack using the baseline version of every provider in
+// baselineVersions, keyed by provider name, then previews the stack with the current provider configuration.
+// This is for programs where providers must be upgraded together, such as a component provider and the provider it
+// depends on. New versions of each provider can be attached using optproviderupgrade.NewProvider.
+// The {baselineVersion} cache directory placeholder is replaced with the combined version returned by
+// MultiProviderBaselineVersion.
+func PreviewMultiProviderUpgrade(t pulumitest.PT, pulumiTest *pulumitest.PulumiTest, baselineVersions map[string]string, opts ...optproviderupgrade.PreviewProviderUpgradeOpt) auto.PreviewResult {
+	t.Helper()
+	if len(baselineVersions) == 0 {
+		t.Log("at least one provider baseline version is required")
+		t.FailNow()
+		return auto.PreviewResult{}
+	}
+	options := optproviderupgrade.Defaults()
+	for _, opt := range opts {
+		opt.Apply(&options)
+	}
+	providerNames := sortedKeys(baselineVersions)
+	var baselineProviderOpts []opttest.Option
+	for _, name := range providerNames {
+		baselineProviderOpts = append(baselineProviderOpts, baselineProviderOpt(options, name, baselineVersions[name]))
+	}
+	previewTest := prepareBaselineTest(t, pulumiTest, strings.Join(providerNames, ","), MultiProviderBaselineVersion(baselineVersions),
+		options, optnewstack.DisableAutoDestroy(), baselineProviderOpts...)
+	return previewTest.Preview(t, optpreview.Diff())
+}
+
+// MultiProviderBaselineVersion combines the baseline version of each provider into a single version for use in the
+// cache directory, sorted by provider name, such as "aws-6.0.0_awsx-2.0.0".
+func MultiProviderBaselineVersion(baselineVersions map[string]string) string {
+	var versions []string
+	for _, name := range sortedKeys(baselineVersions) {
+		versions = append(versions, name+"-"+baselineVersions[name])
+	}
+	return strings.Join(versions, "_")
+}
@@ -0,0 +1,32 @@
+package providertest_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/providertest"
+	"github.com/pulumi/providertest/optproviderupgrade"
+	"github.com/pulumi/providertest/pulumitest"
+	"github.com/pulumi/providertest/pulumitest/assertpreview"
+	"github.com/pulumi/providertest/pulumitest/opttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviewMultiProviderUpgrade(t *testing.T) {
+	t.Parallel()
+	cacheDir := t.TempDir()
+	test := pulumitest.NewPulumiTest(t, filepath.Join("pulumitest", "testdata", "yaml_program"),
+		opttest.DownloadProviderVersion("random", "4.15.0"))
+
+	previewResult := providertest.PreviewMultiProviderUpgrade(t, test, map[string]string{"random": "4.5.0"},
+		optproviderupgrade.CacheDir(cacheDir, "{programName}", "{baselineVersion}"),
+		optproviderupgrade.DisableAttach())
+	assertpreview.HasNoReplacements(t, previewResult)
+	assert.FileExists(t, filepath.Join(cacheDir, "yaml_program", "random-4.5.0", "stack.json"))
+}
+
+func TestMultiProviderBaselineVersion(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "aws-6.0.0_awsx-2.0.0",
+		providertest.MultiProviderBaselineVersion(map[string]string{"awsx": "2.0.0", "aws": "6.0.0"}))
+}
@@ -0,0 +1,75 @@
+package providertest
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pulumi/providertest/cachefile"
+	"github.com/pulumi/providertest/optproviderupgrade"
+	"github.com/pulumi/providertest/pulumitest"
+	"github.com/pulumi/providertest/pulumitest/optnewstack"
+	"github.com/pulumi/providertest/pulumitest/optrun"
+	"github.com/pulumi/providertest/pulumitest/opttest"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optpreview"
+)
+
+// PreviewProviderDowngrade captures the state of a stack deployed with the current provider configuration, then
+// previews the stack using an older version of the provider. This shows whether state written by the current provider
+// can still be used after rolling back to the target version.
+// Options are the same as for PreviewProviderUpgrade except that BaselineOpts apply to the deployment with the current
+// provider and the {baselineVersion} cache directory placeholder is replaced with the target version.
+// Use optproviderupgrade.CurrentProviderVersion to record the current provider's version with the cached state so
+// it's detected as stale when the provider changes.
+// Uses a default cache directory of "testdata/recorded/TestProviderDowngrade/{programName}/{baselineVersion}".
+func PreviewProviderDowngrade(t pulumitest.PT, pulumiTest *pulumitest.PulumiTest, providerName string, targetVersion string, opts ...optproviderupgrade.PreviewProviderUpgradeOpt) auto.PreviewResult {
+	t.Helper()
+	options := optproviderupgrade.DowngradeDefaults()
+	for _, opt := range opts {
+		opt.Apply(&options)
+	}
+	programName := filepath.Base(pulumiTest.WorkingDir())
+	cacheDir := GetDowngradeCacheDir(programName, targetVersion, options.CacheDirTemplate...)
+
+	// The state is written by the current provider, so its version is what makes the cached state stale.
+	if options.CurrentProviderVersion == "" {
+		t.Log("no current provider version set with optproviderupgrade.CurrentProviderVersion, " +
+			"so the cached state won't be detected as stale when the provider changes")
+	}
+	metadata := newBaselineMetadata(t, pulumiTest.WorkingDir(), providerName, options.CurrentProviderVersion)
+	checkBaselineCache(t, cacheDir, metadata, options.StaleBaselineAction)
+	stackPath, _ := baselineCachePaths(cacheDir, options.CompressCache)
+	if _, err := os.Stat(cachefile.Resolve(stackPath)); os.IsNotExist(err) {
+		// Record the state with the current provider configuration. The recorded state is only imported into this
+		// copy so that it's written to the cache, so it must not be destroyed separately.
+		recordTest := pulumiTest.CopyToTempDir(t, opttest.NewStackOptions(optnewstack.DisableAutoDestroy()))
+		runBaseline(t, recordTest, cacheDir, metadata, options)
+	}
+
+	downgradeTest := pulumiTest.CopyToTempDir(t,
+		opttest.NewStackOptions(optnewstack.DisableAutoDestroy()),
+		baselineProviderOpt(options, providerName, targetVersion))
+	downgradeTest.Run(t,
+		func(test *pulumitest.PulumiTest) {
+			t.Helper()
+			t.Log("expected recorded state to be cached before previewing the downgrade")
+			t.FailNow()
+		},
+		optrun.WithCache(stackPath),
+	)
+
+	if options.NewSourcePath != "" {
+		downgradeTest.UpdateSource(t, options.NewSourcePath)
+	}
+	return downgradeTest.Preview(t, optpreview.Diff())
+}
+
+// GetDowngradeCacheDir returns the cache directory for a provider downgrade test.
+// If no cacheDirTemplatePath is provided, the default downgrade cache directory is used.
+func GetDowngradeCacheDir(programName, targetVersion string, cacheDirTemplatePath ...string) string {
+	cacheDirTemplate := cacheDirTemplatePath
+	if len(cacheDirTemplate) == 0 {
+		cacheDirTemplate = optproviderupgrade.DowngradeDefaults().CacheDirTemplate
+	}
+	return expandCacheDirTemplate(cacheDirTemplate, programName, targetVersion)
+}
@@ -0,0 +1,36 @@
+package providertest_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/providertest"
+	"github.com/pulumi/providertest/optproviderupgrade"
+	"github.com/pulumi/providertest/pulumitest"
+	"github.com/pulumi/providertest/pulumitest/assertpreview"
+	"github.com/pulumi/providertest/pulumitest/opttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviewDowngrade(t *testing.T) {
+	t.Parallel()
+	cacheDir := t.TempDir()
+	test := pulumitest.NewPulumiTest(t, filepath.Join("pulumitest", "testdata", "yaml_program"),
+		opttest.DownloadProviderVersion("random", "4.15.0"))
+
+	previewResult := providertest.PreviewProviderDowngrade(t, test, "random", "4.5.0",
+		optproviderupgrade.CacheDir(cacheDir, "{programName}", "{baselineVersion}"),
+		optproviderupgrade.CurrentProviderVersion("4.15.0"),
+		optproviderupgrade.DisableAttach())
+	assertpreview.HasNoReplacements(t, previewResult)
+	assert.FileExists(t, filepath.Join(cacheDir, "yaml_program", "4.5.0", "stack.json"))
+	assert.FileExists(t, filepath.Join(cacheDir, "yaml_program", "4.5.0", "grpc.json"))
+}
+
+func TestGetDowngradeCacheDir(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, filepath.Join("testdata", "recorded", "TestProviderDowngrade", "prog", "1.0.0"),
+		providertest.GetDowngradeCacheDir("prog", "1.0.0"))
+	assert.Equal(t, filepath.Join("cache", "1.0.0", "prog"),
+		providertest.GetDowngradeCacheDir("prog", "1.0.0", "cache", "{baselineVersion}", "{programName}"))
+}
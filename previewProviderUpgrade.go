@@ -3,6 +3,7 @@ package providertest
 import (
 	"fmt"
 	"path/filepath"
+	"time"
 
 	"github.com/pulumi/providertest/optproviderupgrade"
 	"github.com/pulumi/providertest/pulumitest"
@@ -18,36 +19,84 @@ import (
 // Uses a default cache directory of "testdata/recorded/TestProviderUpgrade/{programName}/{baselineVersion}".
 func PreviewProviderUpgrade(t pulumitest.PT, pulumiTest *pulumitest.PulumiTest, providerName string, baselineVersion string, opts ...optproviderupgrade.PreviewProviderUpgradeOpt) auto.PreviewResult {
 	t.Helper()
-	previewTest := pulumiTest.CopyToTempDir(t, opttest.NewStackOptions(optnewstack.DisableAutoDestroy()))
 	options := optproviderupgrade.Defaults()
 	for _, opt := range opts {
 		opt.Apply(&options)
 	}
+	previewTest := prepareUpgradeTest(t, pulumiTest, providerName, baselineVersion, options, optnewstack.DisableAutoDestroy())
+	return previewTest.Preview(t, optpreview.Diff())
+}
+
+// prepareUpgradeTest copies the program under test to a temporary directory, then imports the baseline stack state
+// into it - recording the baseline first if it's not already cached or is stale.
+// If a new source path is configured, the source is updated before returning.
+// The stackOpt controls whether the stack with the new provider is destroyed at the end of the test.
+func prepareUpgradeTest(t pulumitest.PT, pulumiTest *pulumitest.PulumiTest, providerName string, baselineVersion string,
+	options optproviderupgrade.PreviewProviderUpgradeOptions, stackOpt optnewstack.NewStackOpt) *pulumitest.PulumiTest {
+	t.Helper()
+	return prepareBaselineTest(t, pulumiTest, providerName, baselineVersion, options, stackOpt,
+		baselineProviderOpt(options, providerName, baselineVersion))
+}
+
+// prepareBaselineTest is the same as prepareUpgradeTest but with the baseline providers configured by
+// baselineProviderOpts. The providerName and baselineVersion are used for the cache directory and baseline metadata.
+func prepareBaselineTest(t pulumitest.PT, pulumiTest *pulumitest.PulumiTest, providerName string, baselineVersion string,
+	options optproviderupgrade.PreviewProviderUpgradeOptions, stackOpt optnewstack.NewStackOpt, baselineProviderOpts ...opttest.Option) *pulumitest.PulumiTest {
+	t.Helper()
+	upgradeOpts := []opttest.Option{opttest.NewStackOptions(stackOpt)}
+	for _, name := range sortedKeys(options.NewProviders) {
+		upgradeOpts = append(upgradeOpts, opttest.AttachProvider(name, options.NewProviders[name]))
+	}
+	upgradeTest := pulumiTest.CopyToTempDir(t, upgradeOpts...)
 	programName := filepath.Base(pulumiTest.WorkingDir())
 	cacheDir := GetUpgradeCacheDir(programName, baselineVersion, options.CacheDirTemplate...)
-	previewTest.Run(t,
+	runCachedBaseline(t, upgradeTest, pulumiTest.WorkingDir(), cacheDir, providerName, baselineVersion, options,
+		baselineProviderOpts...)
+
+	if options.NewSourcePath != "" {
+		upgradeTest.UpdateSource(t, options.NewSourcePath)
+	}
+	return upgradeTest
+}
+
+// runCachedBaseline imports the cached baseline stack state into the test, first checking the cache is not stale.
+// If there's no cached state, the program is deployed in an isolated copy of the test with the additional recordOpts,
+// then the sanitized stack state, gRPC log and metadata are written to the cache directory.
+func runCachedBaseline(t pulumitest.PT, test *pulumitest.PulumiTest, sourceDir, cacheDir, providerName, baselineVersion string,
+	options optproviderupgrade.PreviewProviderUpgradeOptions, recordOpts ...opttest.Option) {
+	t.Helper()
+	metadata := newBaselineMetadata(t, sourceDir, providerName, baselineVersion)
+	checkBaselineCache(t, cacheDir, metadata, options.StaleBaselineAction)
+	runBaseline(t, test, cacheDir, metadata, options, recordOpts...)
+}
+
+// runBaseline imports the cached baseline stack state into the test, or records it if there's no cached state.
+func runBaseline(t pulumitest.PT, test *pulumitest.PulumiTest, cacheDir string, metadata baselineMetadata,
+	options optproviderupgrade.PreviewProviderUpgradeOptions, recordOpts ...opttest.Option) {
+	t.Helper()
+	stackPath, grpcLogPath := baselineCachePaths(cacheDir, options.CompressCache)
+	test.Run(t,
 		func(test *pulumitest.PulumiTest) {
 			t.Helper()
 			test.Up(t)
 			grptLog := test.GrpcLog(t)
-			grpcLogPath := filepath.Join(cacheDir, "grpc.json")
 			t.Log(fmt.Sprintf("writing grpc log to %s", grpcLogPath))
 			grptLog.SanitizeSecrets()
+			if options.CompactCache {
+				grptLog.Compact()
+			}
 			if err := grptLog.WriteTo(grpcLogPath); err != nil {
 				t.Log(fmt.Sprintf("failed to write grpc log: %v", err))
 			}
+			metadata.RecordedAt = time.Now().UTC()
+			if err := writeBaselineMetadata(cacheDir, metadata); err != nil {
+				t.Log(fmt.Sprintf("failed to write baseline metadata: %v", err))
+			}
 		},
-		optrun.WithCache(filepath.Join(cacheDir, "stack.json")),
-		optrun.WithOpts(
-			opttest.NewStackOptions(optnewstack.EnableAutoDestroy()),
-			baselineProviderOpt(options, providerName, baselineVersion)),
+		optrun.WithCache(stackPath),
+		optrun.WithOpts(append([]opttest.Option{opttest.NewStackOptions(optnewstack.EnableAutoDestroy())}, recordOpts...)...),
 		optrun.WithOpts(options.BaselineOpts...),
 	)
-
-	if options.NewSourcePath != "" {
-		previewTest.UpdateSource(t, options.NewSourcePath)
-	}
-	return previewTest.Preview(t, optpreview.Diff())
 }
 
 func baselineProviderOpt(options optproviderupgrade.PreviewProviderUpgradeOptions, providerName string, baselineVersion string) opttest.Option {
@@ -65,6 +114,10 @@ func GetUpgradeCacheDir(programName, baselineVersion string, cacheDirTemplatePat
 	if len(cacheDirTemplate) == 0 {
 		cacheDirTemplate = optproviderupgrade.Defaults().CacheDirTemplate
 	}
+	return expandCacheDirTemplate(cacheDirTemplate, programName, baselineVersion)
+}
+
+func expandCacheDirTemplate(cacheDirTemplate []string, programName, baselineVersion string) string {
 	var cacheDir string
 	for _, pathTemplateElement := range cacheDirTemplate {
 		switch pathTemplateElement {
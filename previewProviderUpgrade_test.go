@@ -31,6 +31,26 @@ func TestPreviewUpgradeCached(t *testing.T) {
 	assert.Equal(t, uncachedPreviewResult, cachedPreviewResult, "expected uncached and cached preview to be the same")
 }
 
+func TestPreviewUpgradeCompressedCache(t *testing.T) {
+	t.Parallel()
+	cacheDir := t.TempDir()
+	test := pulumitest.NewPulumiTest(t, filepath.Join("pulumitest", "testdata", "yaml_program"),
+		opttest.DownloadProviderVersion("random", "4.15.0"))
+
+	uncachedPreviewResult := providertest.PreviewProviderUpgrade(t, test, "random", "4.5.0",
+		optproviderupgrade.CacheDir(cacheDir),
+		optproviderupgrade.DisableAttach(),
+		optproviderupgrade.CompactCache(),
+		optproviderupgrade.CompressCache())
+	assert.FileExists(t, filepath.Join(cacheDir, "stack.json.gz"))
+	assert.FileExists(t, filepath.Join(cacheDir, "grpc.json.gz"))
+
+	cachedPreviewResult := providertest.PreviewProviderUpgrade(t, test, "random", "4.5.0",
+		optproviderupgrade.CacheDir(cacheDir),
+		optproviderupgrade.DisableAttach())
+	assert.Equal(t, uncachedPreviewResult, cachedPreviewResult, "expected uncached and cached preview to be the same")
+}
+
 func TestPreviewUpgradeWithKnownSourceEdit(t *testing.T) {
 	t.Parallel()
 	cacheDir := t.TempDir()
@@ -0,0 +1,85 @@
+package providertest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pulumi/providertest/optproviderupgrade"
+	"github.com/pulumi/providertest/pulumitest"
+	"github.com/pulumi/providertest/pulumitest/changesummary"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+)
+
+// BaselinePreviewResult is the result of previewing an upgrade from a single baseline version.
+type BaselinePreviewResult struct {
+	BaselineVersion string
+	Result          auto.PreviewResult
+}
+
+// BaselinePreviewResults are the results of previewing upgrades from several baseline versions, in the order the
+// baseline versions were given.
+type BaselinePreviewResults []BaselinePreviewResult
+
+// PreviewProviderUpgrades runs PreviewProviderUpgrade for each of the baseline versions as a subtest named after the
+// version. Each baseline is recorded and cached in its own directory, as returned by GetUpgradeCacheDir.
+// Results are only returned for baselines whose subtest completed the preview.
+func PreviewProviderUpgrades(t *testing.T, pulumiTest *pulumitest.PulumiTest, providerName string, baselineVersions []string, opts ...optproviderupgrade.PreviewProviderUpgradeOpt) BaselinePreviewResults {
+	t.Helper()
+	var results BaselinePreviewResults
+	for _, baselineVersion := range baselineVersions {
+		t.Run(baselineVersion, func(t *testing.T) {
+			result := PreviewProviderUpgrade(t, pulumiTest, providerName, baselineVersion, opts...)
+			results = append(results, BaselinePreviewResult{
+				BaselineVersion: baselineVersion,
+				Result:          result,
+			})
+		})
+	}
+	return results
+}
+
+var replacementOps = []apitype.OpType{apitype.OpReplace, apitype.OpCreateReplacement, apitype.OpDeleteReplaced,
+	apitype.OpDiscardReplaced, apitype.OpImportReplacement, apitype.OpReadReplacement}
+
+// Replacements returns the replacement operations in the preview's change summary.
+func (r BaselinePreviewResult) Replacements() *changesummary.ChangeSummary {
+	summary := changesummary.ChangeSummary(r.Result.ChangeSummary)
+	return summary.WhereOpEquals(replacementOps...)
+}
+
+// Changes returns the operations in the preview's change summary which are not "same".
+func (r BaselinePreviewResult) Changes() *changesummary.ChangeSummary {
+	summary := changesummary.ChangeSummary(r.Result.ChangeSummary)
+	return summary.WhereOpNotEquals(apitype.OpSame)
+}
+
+// WithReplacements returns the baseline versions whose upgrade preview contains replacements.
+func (results BaselinePreviewResults) WithReplacements() []string {
+	var versions []string
+	for _, r := range results {
+		if len(*r.Replacements()) > 0 {
+			versions = append(versions, r.BaselineVersion)
+		}
+	}
+	return versions
+}
+
+// Summary returns a human-readable summary of the changes for each baseline version, flagging the baselines which
+// would cause replacements when upgrading.
+func (results BaselinePreviewResults) Summary() string {
+	var lines []string
+	for _, r := range results {
+		changes := r.Changes()
+		switch {
+		case len(*r.Replacements()) > 0:
+			lines = append(lines, fmt.Sprintf("%s: REPLACEMENTS (%s)", r.BaselineVersion, changes))
+		case len(*changes) > 0:
+			lines = append(lines, fmt.Sprintf("%s: changes (%s)", r.BaselineVersion, changes))
+		default:
+			lines = append(lines, fmt.Sprintf("%s: no changes", r.BaselineVersion))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,48 @@
+package providertest_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/providertest"
+	"github.com/pulumi/providertest/optproviderupgrade"
+	"github.com/pulumi/providertest/pulumitest"
+	"github.com/pulumi/providertest/pulumitest/opttest"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviewUpgrades(t *testing.T) {
+	t.Parallel()
+	cacheDir := t.TempDir()
+	test := pulumitest.NewPulumiTest(t, filepath.Join("pulumitest", "testdata", "yaml_program"),
+		opttest.DownloadProviderVersion("random", "4.15.0"))
+
+	results := providertest.PreviewProviderUpgrades(t, test, "random", []string{"4.5.0", "4.14.0"},
+		optproviderupgrade.CacheDir(cacheDir, "{programName}", "{baselineVersion}"),
+		optproviderupgrade.DisableAttach())
+
+	assert.Len(t, results, 2)
+	assert.Empty(t, results.WithReplacements())
+	assert.DirExists(t, filepath.Join(cacheDir, "yaml_program", "4.5.0"))
+	assert.DirExists(t, filepath.Join(cacheDir, "yaml_program", "4.14.0"))
+}
+
+func TestBaselinePreviewResultsSummary(t *testing.T) {
+	t.Parallel()
+	results := providertest.BaselinePreviewResults{
+		{BaselineVersion: "5.0.0", Result: auto.PreviewResult{ChangeSummary: map[apitype.OpType]int{
+			apitype.OpSame: 3, apitype.OpReplace: 1,
+		}}},
+		{BaselineVersion: "5.30.0", Result: auto.PreviewResult{ChangeSummary: map[apitype.OpType]int{
+			apitype.OpSame: 3, apitype.OpUpdate: 1,
+		}}},
+		{BaselineVersion: "6.0.0", Result: auto.PreviewResult{ChangeSummary: map[apitype.OpType]int{
+			apitype.OpSame: 4,
+		}}},
+	}
+
+	assert.Equal(t, []string{"5.0.0"}, results.WithReplacements())
+	assert.Equal(t, "5.0.0: REPLACEMENTS (1 replace)\n5.30.0: changes (1 update)\n6.0.0: no changes", results.Summary())
+}
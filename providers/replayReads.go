@@ -0,0 +1,144 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pulumi/providertest/grpclog"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+)
+
+// ReplayReads wraps a provider factory, intercepting all reads and replaying them from a gRPC log.
+// Reads are matched by URN and ID.
+// Example:
+// providerFactory := providers.ResourceProviderFactory(providerServer)
+// factoryWithReplay := providerFactory.ReplayReads(filepath.Join(dir, "grpc.json"), true)
+func (pf ProviderFactory) ReplayReads(grpcLogPath string, allowLiveFallback bool) ProviderFactory {
+	return pf.replayReads(grpcLogPath, allowLiveFallback, false)
+}
+
+// ReplayReadsFromOutputs is the same as ReplayReads, except that where the log contains no matching read, a response
+// is built from the inputs and outputs of the last create or update of the resource. This allows the log recorded for
+// an upgrade baseline, which only contains the resources being created, to be used to refresh without calling the
+// cloud provider.
+// Example:
+// providerFactory := providers.ResourceProviderFactory(providerServer)
+// cacheDir := providertest.GetUpgradeCacheDir(filepath.Base(dir), "5.60.0")
+// factoryWithReplay := providerFactory.ReplayReadsFromOutputs(filepath.Join(cacheDir, "grpc.json"), false)
+func (pf ProviderFactory) ReplayReadsFromOutputs(grpcLogPath string, allowLiveFallback bool) ProviderFactory {
+	return pf.replayReads(grpcLogPath, allowLiveFallback, true)
+}
+
+func (pf ProviderFactory) replayReads(grpcLogPath string, allowLiveFallback, fromOutputs bool) ProviderFactory {
+	interceptors := ProviderInterceptors{
+		Read: func(ctx context.Context, in *pulumirpc.ReadRequest, client pulumirpc.ResourceProviderClient) (*pulumirpc.ReadResponse, error) {
+			log, err := grpclog.LoadLog(grpcLogPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load gRPC log: %w", err)
+			}
+			response, err := findRecordedRead(log, in.GetUrn(), in.GetId())
+			if err != nil {
+				return nil, err
+			}
+			if response == nil && fromOutputs {
+				response, err = readFromRecordedOutputs(log, in.GetUrn(), in.GetId())
+				if err != nil {
+					return nil, err
+				}
+			}
+			if response != nil {
+				return response, nil
+			}
+			if allowLiveFallback {
+				return client.Read(ctx, in)
+			} else {
+				return nil, fmt.Errorf("failed to find read of %s with ID %s in gRPC log", in.GetUrn(), in.GetId())
+			}
+		},
+	}
+	return func(ctx context.Context, pt PulumiTest) (Port, error) {
+		port, err := pf(ctx, pt)
+		if err != nil {
+			return -1, err
+		}
+		interceptResourceProviderServer, err := NewProviderInterceptProxy(ctx, port, interceptors)
+		if err != nil {
+			return -1, err
+		}
+		return startResourceProviderServer(ctx, pt, func(pt PulumiTest) (pulumirpc.ResourceProviderServer, error) {
+			return interceptResourceProviderServer, nil
+		})
+	}
+}
+
+// findRecordedRead returns the response to the last recorded read of the resource, or nil if not found.
+func findRecordedRead(log *grpclog.GrpcLog, urn, id string) (*pulumirpc.ReadResponse, error) {
+	reads, err := log.Reads()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reads from log: %w", err)
+	}
+	if read := findLastByURN(reads, urn, func(entry *grpclog.TypedEntry[pulumirpc.ReadRequest, pulumirpc.ReadResponse]) bool {
+		return entry.Request.GetId() == id && entry.Response.GetId() != ""
+	}); read != nil {
+		return &read.Response, nil
+	}
+	return nil, nil
+}
+
+// readFromRecordedOutputs builds a read response from the last create or update of the resource, or returns nil if
+// not found.
+func readFromRecordedOutputs(log *grpclog.GrpcLog, urn, id string) (*pulumirpc.ReadResponse, error) {
+	updates, err := log.Updates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get updates from log: %w", err)
+	}
+	if update := findLastByURN(updates, urn, func(entry *grpclog.TypedEntry[pulumirpc.UpdateRequest, pulumirpc.UpdateResponse]) bool {
+		return entry.Request.GetId() == id && !entry.Request.GetPreview()
+	}); update != nil {
+		return &pulumirpc.ReadResponse{
+			Id:         id,
+			Properties: update.Response.GetProperties(),
+			Inputs:     update.Request.GetNews(),
+		}, nil
+	}
+
+	creates, err := log.Creates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get creates from log: %w", err)
+	}
+	if create := findLastByURN(creates, urn, func(entry *grpclog.TypedEntry[pulumirpc.CreateRequest, pulumirpc.CreateResponse]) bool {
+		return entry.Response.GetId() == id && !entry.Request.GetPreview()
+	}); create != nil {
+		return &pulumirpc.ReadResponse{
+			Id:         id,
+			Properties: create.Response.GetProperties(),
+			Inputs:     create.Request.GetProperties(),
+		}, nil
+	}
+	return nil, nil
+}
+
+type replayedRequest interface {
+	pulumirpc.ReadRequest | pulumirpc.CreateRequest | pulumirpc.UpdateRequest
+}
+
+type replayedResponse interface {
+	pulumirpc.ReadResponse | pulumirpc.CreateResponse | pulumirpc.UpdateResponse
+}
+
+// findLastByURN returns the last entry for the URN which also matches the predicate, or nil if none match.
+func findLastByURN[TRequest replayedRequest, TResponse replayedResponse](entries []grpclog.TypedEntry[TRequest, TResponse],
+	urn string, match func(*grpclog.TypedEntry[TRequest, TResponse]) bool) *grpclog.TypedEntry[TRequest, TResponse] {
+	var last *grpclog.TypedEntry[TRequest, TResponse]
+	for len(entries) > 0 {
+		entry, i := grpclog.FindByURN(entries, urn)
+		if entry == nil {
+			break
+		}
+		if match(entry) {
+			last = entry
+		}
+		entries = entries[i+1:]
+	}
+	return last
+}
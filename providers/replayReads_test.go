@@ -0,0 +1,75 @@
+package providers_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/providertest/providers"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestReplayReads(t *testing.T) {
+	t.Parallel()
+	const bucketURN = "urn:pulumi:p-it-antons-mac-bucket-9f59db4a::test::aws:s3/bucket:Bucket::tested-resource"
+	const bucketID = "testbucket-p-it-antons-mac-bucket-9f59db4a"
+	grpcLogPath := filepath.Join("..", "grpclog", "testdata", "aws_bucket_grpc.json")
+
+	startClient := func(t *testing.T, allowLiveFallback, fromOutputs bool) pulumirpc.ResourceProviderClient {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		factory := providers.ProviderMockFactory(providers.ProviderMocks{
+			Read: func(ctx context.Context, in *pulumirpc.ReadRequest) (*pulumirpc.ReadResponse, error) {
+				return &pulumirpc.ReadResponse{Id: "live"}, nil
+			},
+		})
+		if fromOutputs {
+			factory = factory.ReplayReadsFromOutputs(grpcLogPath, allowLiveFallback)
+		} else {
+			factory = factory.ReplayReads(grpcLogPath, allowLiveFallback)
+		}
+		port, err := factory(ctx, stubPulumiTest{})
+		require.NoError(t, err)
+		conn, err := grpc.NewClient(fmt.Sprintf("127.0.0.1:%d", port), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+		return pulumirpc.NewResourceProviderClient(conn)
+	}
+
+	t.Run("replays recorded create", func(t *testing.T) {
+		t.Parallel()
+		client := startClient(t, false, true)
+		resp, err := client.Read(context.Background(), &pulumirpc.ReadRequest{Urn: bucketURN, Id: bucketID})
+		require.NoError(t, err)
+		assert.Equal(t, bucketID, resp.GetId())
+		assert.Equal(t, "private", resp.GetProperties().GetFields()["acl"].GetStringValue())
+		assert.Equal(t, "private", resp.GetInputs().GetFields()["acl"].GetStringValue())
+	})
+
+	t.Run("live fallback", func(t *testing.T) {
+		t.Parallel()
+		client := startClient(t, true, true)
+		resp, err := client.Read(context.Background(), &pulumirpc.ReadRequest{Urn: bucketURN, Id: "other"})
+		require.NoError(t, err)
+		assert.Equal(t, "live", resp.GetId())
+	})
+
+	t.Run("no fallback", func(t *testing.T) {
+		t.Parallel()
+		client := startClient(t, false, true)
+		_, err := client.Read(context.Background(), &pulumirpc.ReadRequest{Urn: bucketURN, Id: "other"})
+		assert.ErrorContains(t, err, "failed to find read")
+	})
+
+	t.Run("no recorded read", func(t *testing.T) {
+		t.Parallel()
+		client := startClient(t, false, false)
+		_, err := client.Read(context.Background(), &pulumirpc.ReadRequest{Urn: bucketURN, Id: bucketID})
+		assert.ErrorContains(t, err, "failed to find read")
+	})
+}
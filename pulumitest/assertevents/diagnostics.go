@@ -0,0 +1,39 @@
+package assertevents
+
+import (
+	"github.com/pulumi/providertest/pulumitest/engineevents"
+)
+
+// TestingT is the subset of *testing.T used to report failed assertions.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// HasNoWarnings asserts that no warnings were reported during an operation, other than those matching an allowed
+// matcher. Use the events captured by the PulumiTest, for example: HasNoWarnings(t, test.Events()).
+func HasNoWarnings(t TestingT, events engineevents.Events, allowed ...engineevents.DiagnosticMatcher) {
+	t.Helper()
+
+	HasNoDiagnostics(t, events, engineevents.SeverityWarning, allowed...)
+}
+
+// HasNoDiagnostics asserts that no diagnostics with the given severity were reported during an operation, other than
+// those matching an allowed matcher.
+func HasNoDiagnostics(t TestingT, events engineevents.Events, severity string, allowed ...engineevents.DiagnosticMatcher) {
+	t.Helper()
+
+	unexpected := events.UnexpectedDiagnostics([]string{severity}, allowed...)
+	if len(unexpected) > 0 {
+		t.Errorf("expected no %s diagnostics, got %d:\n%s", severity, len(unexpected), engineevents.FormatDiagnostics(unexpected))
+	}
+}
+
+// HasDiagnostic asserts that at least one diagnostic reported during an operation matches the matcher.
+func HasDiagnostic(t TestingT, events engineevents.Events, matcher engineevents.DiagnosticMatcher) {
+	t.Helper()
+
+	if len(events.MatchingDiagnostics(matcher)) == 0 {
+		t.Errorf("expected a diagnostic with %s, got:\n%s", matcher, engineevents.FormatDiagnostics(events.Diagnostics()))
+	}
+}
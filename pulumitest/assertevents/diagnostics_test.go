@@ -0,0 +1,76 @@
+package assertevents_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pulumi/providertest/pulumitest/assertevents"
+	"github.com/pulumi/providertest/pulumitest/engineevents"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/stretchr/testify/assert"
+)
+
+const bucketURN = "urn:pulumi:test::project::aws:s3/bucket:Bucket::bucket"
+
+// recordingT records the errors reported by an assertion.
+type recordingT struct {
+	errors []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func testEvents() engineevents.Events {
+	diagnostic := func(severity, message string) events.EngineEvent {
+		return events.EngineEvent{EngineEvent: apitype.EngineEvent{DiagnosticEvent: &apitype.DiagnosticEvent{
+			URN: bucketURN, Message: message, Severity: severity,
+		}}}
+	}
+	return engineevents.Events{
+		diagnostic(engineevents.SeverityWarning, "acl is deprecated"),
+		diagnostic(engineevents.SeverityError, "versioning failed"),
+	}
+}
+
+func TestHasNoWarnings(t *testing.T) {
+	t.Parallel()
+
+	tt := &recordingT{}
+	assertevents.HasNoWarnings(tt, testEvents())
+	assert.Len(t, tt.errors, 1)
+	assert.Contains(t, tt.errors[0], "expected no warning diagnostics, got 1")
+	assert.Contains(t, tt.errors[0], "acl is deprecated")
+
+	tt = &recordingT{}
+	assertevents.HasNoWarnings(tt, testEvents(), engineevents.MatchDiagnostic(bucketURN, "deprecated"))
+	assert.Empty(t, tt.errors)
+}
+
+func TestHasNoDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	tt := &recordingT{}
+	assertevents.HasNoDiagnostics(tt, testEvents(), engineevents.SeverityError, engineevents.MatchMessage("deprecated"))
+	assert.Len(t, tt.errors, 1)
+	assert.Contains(t, tt.errors[0], "versioning failed")
+
+	tt = &recordingT{}
+	assertevents.HasNoDiagnostics(tt, testEvents(), engineevents.SeverityError, engineevents.MatchMessage("^versioning"))
+	assert.Empty(t, tt.errors)
+}
+
+func TestHasDiagnostic(t *testing.T) {
+	t.Parallel()
+
+	tt := &recordingT{}
+	assertevents.HasDiagnostic(tt, testEvents(), engineevents.MatchMessage("acl .* deprecated"))
+	assert.Empty(t, tt.errors)
+
+	assertevents.HasDiagnostic(tt, testEvents(), engineevents.MatchMessage("tags"))
+	assert.Len(t, tt.errors, 1)
+	assert.Contains(t, tt.errors[0], `expected a diagnostic with message matching "tags"`)
+}
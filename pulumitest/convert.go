@@ -23,7 +23,7 @@ func Convert(t PT, source, language string, opts ...opttest.Option) ConvertResul
 	t.Helper()
 
 	pulumiTest := PulumiTest{
-		ctx:        testContext(t),
+		ctx:        TestContext(t),
 		workingDir: source,
 		options:    opttest.DefaultOptions(),
 	}
@@ -23,18 +23,61 @@ func (pt *PulumiTest) Destroy(t PT, opts ...optdestroy.Option) auto.DestroyResul
 func (pt *PulumiTest) DestroyErr(t PT, opts ...optdestroy.Option) (auto.DestroyResult, error) {
 	t.Helper()
 
+	return pt.destroyStack(t, pt.currentStack, opts...)
+}
+
+// DestroyStack destroys the named stack, which must have been created by this test.
+// If an error is expected, use `DestroyStackErr` instead to have the error returned.
+func (pt *PulumiTest) DestroyStack(t PT, stackName string, opts ...optdestroy.Option) auto.DestroyResult {
+	t.Helper()
+
+	result, err := pt.DestroyStackErr(t, stackName, opts...)
+	if err != nil {
+		ptFatalF(t, "failed to destroy: %s", err)
+	}
+	return result
+}
+
+// DestroyStackErr destroys the named stack and returns any error instead of failing the test.
+func (pt *PulumiTest) DestroyStackErr(t PT, stackName string, opts ...optdestroy.Option) (auto.DestroyResult, error) {
+	t.Helper()
+
+	stack, err := pt.stackNamed(stackName)
+	if err != nil {
+		return auto.DestroyResult{}, err
+	}
+	return pt.destroyStack(t, stack, opts...)
+}
+
+func (pt *PulumiTest) destroyStack(t PT, stack *auto.Stack, opts ...optdestroy.Option) (auto.DestroyResult, error) {
+	t.Helper()
+
 	t.Log("destroying")
-	if pt.currentStack == nil {
+	if stack == nil {
 		return auto.DestroyResult{}, fmt.Errorf("no current stack")
 	}
-	if !pt.options.DisableGrpcLog {
-		pt.ClearGrpcLog(t)
+	policy := retryPolicy(pt.options.RetryPolicy, opts)
+	var destroyOptions optdestroy.Options
+	for _, opt := range opts {
+		opt.ApplyOption(&destroyOptions)
 	}
-	var result auto.DestroyResult
-	err := pt.withProviders(t, pt.currentStack, func() error {
-		var destroyErr error
-		result, destroyErr = pt.currentStack.Destroy(pt.ctx, opts...)
-		return destroyErr
+	forwarder := newEventForwarder(destroyOptions.EventStreams)
+	defer forwarder.close()
+	return withRetry(t, pt.ctx, "destroy", policy, func() (auto.DestroyResult, error) {
+		if !pt.options.DisableGrpcLog {
+			pt.clearGrpcLog(t, stack)
+		}
+		var result auto.DestroyResult
+		capture := forwarder.capture()
+		attemptOpts := append(append([]optdestroy.Option{}, opts...), destroyEventCapture(capture.ch))
+		started := false
+		err := pt.withProviders(t, stack, func() error {
+			started = true
+			var destroyErr error
+			result, destroyErr = stack.Destroy(pt.ctx, attemptOpts...)
+			return destroyErr
+		})
+		pt.lastEvents = capture.wait(t, started)
+		return result, err
 	})
-	return result, err
 }
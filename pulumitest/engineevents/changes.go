@@ -0,0 +1,71 @@
+package engineevents
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+)
+
+// ChangedSteps returns the steps which changed a resource: any operation other than "same", and refreshes which found
+// the resource's state differed from the provider.
+// Steps of the given resource types are ignored, which allows tolerating resources known to be noisy.
+func (e Events) ChangedSteps(ignoredTypes ...string) []apitype.StepEventMetadata {
+	ignored := map[string]bool{}
+	for _, resourceType := range ignoredTypes {
+		ignored[resourceType] = true
+	}
+	var changed []apitype.StepEventMetadata
+	for _, step := range e.Steps() {
+		if ignored[step.Type] || step.Op == apitype.OpSame {
+			continue
+		}
+		if step.Op == apitype.OpRefresh {
+			// The differences found by a refresh are only known once it completes.
+			if outputs := e.ResourceOutputsForURN(step.URN); outputs != nil {
+				step = outputs.Metadata
+			}
+			if !refreshChanged(step) {
+				continue
+			}
+		}
+		changed = append(changed, step)
+	}
+	return changed
+}
+
+func refreshChanged(step apitype.StepEventMetadata) bool {
+	if len(step.Diffs) > 0 || len(step.DetailedDiff) > 0 {
+		return true
+	}
+	if step.Old == nil || step.New == nil {
+		return step.Old != step.New
+	}
+	return !reflect.DeepEqual(step.Old.Outputs, step.New.Outputs)
+}
+
+// FormatSteps formats steps for test output, including the operation, resource URN and the properties which changed.
+// The detailed diff is used where the provider reported one, otherwise the changed property names are listed.
+func FormatSteps(steps []apitype.StepEventMetadata) string {
+	var sb strings.Builder
+	for _, step := range steps {
+		fmt.Fprintf(&sb, "%s: %s\n", step.Op, step.URN)
+		if len(step.DetailedDiff) > 0 {
+			paths := make([]string, 0, len(step.DetailedDiff))
+			for path := range step.DetailedDiff {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			for _, path := range paths {
+				fmt.Fprintf(&sb, "    %s: %s\n", path, step.DetailedDiff[path].Kind)
+			}
+			continue
+		}
+		for _, diff := range step.Diffs {
+			fmt.Fprintf(&sb, "    %s\n", diff)
+		}
+	}
+	return sb.String()
+}
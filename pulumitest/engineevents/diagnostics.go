@@ -0,0 +1,104 @@
+package engineevents
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+)
+
+// DiagnosticMatcher matches diagnostics by resource URN, message pattern and severity.
+// Empty fields match any diagnostic.
+type DiagnosticMatcher struct {
+	// URN of the resource the diagnostic was reported for.
+	URN string
+	// Message is a pattern which must match part of the diagnostic message.
+	Message *regexp.Regexp
+	// Severity of the diagnostic, such as SeverityWarning.
+	Severity string
+}
+
+// MatchMessage matches diagnostics for any resource where the message matches the regular expression.
+func MatchMessage(pattern string) DiagnosticMatcher {
+	return DiagnosticMatcher{Message: regexp.MustCompile(pattern)}
+}
+
+// MatchDiagnostic matches diagnostics for the resource URN where the message matches the regular expression.
+func MatchDiagnostic(urn, pattern string) DiagnosticMatcher {
+	return DiagnosticMatcher{URN: urn, Message: regexp.MustCompile(pattern)}
+}
+
+// Matches returns true if the diagnostic matches all the non-empty fields of the matcher.
+func (m DiagnosticMatcher) Matches(diagnostic apitype.DiagnosticEvent) bool {
+	if m.URN != "" && m.URN != diagnostic.URN {
+		return false
+	}
+	if m.Severity != "" && m.Severity != diagnostic.Severity {
+		return false
+	}
+	return m.Message == nil || m.Message.MatchString(diagnostic.Message)
+}
+
+func (m DiagnosticMatcher) String() string {
+	var parts []string
+	if m.Severity != "" {
+		parts = append(parts, "severity "+m.Severity)
+	}
+	if m.URN != "" {
+		parts = append(parts, "URN "+m.URN)
+	}
+	if m.Message != nil {
+		parts = append(parts, fmt.Sprintf("message matching %q", m.Message.String()))
+	}
+	if len(parts) == 0 {
+		return "any diagnostic"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// MatchingDiagnostics returns the diagnostics which match the matcher.
+func (e Events) MatchingDiagnostics(matcher DiagnosticMatcher) []apitype.DiagnosticEvent {
+	var matching []apitype.DiagnosticEvent
+	for _, diagnostic := range e.Diagnostics() {
+		if matcher.Matches(diagnostic) {
+			matching = append(matching, diagnostic)
+		}
+	}
+	return matching
+}
+
+// UnexpectedDiagnostics returns the diagnostics with any of the given severities which don't match any of the
+// allowed matchers.
+func (e Events) UnexpectedDiagnostics(severities []string, allowed ...DiagnosticMatcher) []apitype.DiagnosticEvent {
+	var unexpected []apitype.DiagnosticEvent
+	for _, diagnostic := range e.DiagnosticsWithSeverity(severities...) {
+		isAllowed := false
+		for _, matcher := range allowed {
+			if matcher.Matches(diagnostic) {
+				isAllowed = true
+				break
+			}
+		}
+		if !isAllowed {
+			unexpected = append(unexpected, diagnostic)
+		}
+	}
+	return unexpected
+}
+
+// FormatDiagnostics formats diagnostics for test output, including the severity, resource URN and full message.
+func FormatDiagnostics(diagnostics []apitype.DiagnosticEvent) string {
+	var sb strings.Builder
+	for _, diagnostic := range diagnostics {
+		urn := diagnostic.URN
+		if urn == "" {
+			urn = "(no resource)"
+		}
+		fmt.Fprintf(&sb, "%s: %s\n", diagnostic.Severity, urn)
+		for _, line := range strings.Split(strings.TrimRight(diagnostic.Message, "\n"), "\n") {
+			fmt.Fprintf(&sb, "    %s\n", line)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,34 @@
+package engineevents_test
+
+import (
+	"testing"
+
+	"github.com/pulumi/providertest/pulumitest/engineevents"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnosticMatcher(t *testing.T) {
+	t.Parallel()
+	e := testEvents()
+
+	assert.Len(t, e.MatchingDiagnostics(engineevents.MatchMessage("deprecated")), 1)
+	assert.Len(t, e.MatchingDiagnostics(engineevents.MatchDiagnostic(passwordURN, "deprecated")), 0)
+	assert.Len(t, e.MatchingDiagnostics(engineevents.MatchDiagnostic(passwordURN, "^replace")), 1)
+	assert.Len(t, e.MatchingDiagnostics(engineevents.DiagnosticMatcher{Severity: engineevents.SeverityError}), 1)
+	assert.Len(t, e.MatchingDiagnostics(engineevents.DiagnosticMatcher{}), 2)
+	assert.Equal(t, `URN `+petURN+`, message matching "length"`,
+		engineevents.MatchDiagnostic(petURN, "length").String())
+}
+
+func TestUnexpectedDiagnostics(t *testing.T) {
+	t.Parallel()
+	e := testEvents()
+	severities := []string{engineevents.SeverityWarning, engineevents.SeverityError}
+
+	assert.Len(t, e.UnexpectedDiagnostics(severities), 2)
+	unexpected := e.UnexpectedDiagnostics(severities, engineevents.MatchMessage("is deprecated"))
+	assert.Len(t, unexpected, 1)
+	assert.Equal(t, "replace failed", unexpected[0].Message)
+
+	assert.Equal(t, "error: "+passwordURN+"\n    replace failed\n", engineevents.FormatDiagnostics(unexpected))
+}
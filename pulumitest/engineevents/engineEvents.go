@@ -0,0 +1,167 @@
+// Package engineevents queries the engine events emitted during a Pulumi operation.
+package engineevents
+
+import (
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+)
+
+// Diagnostic severities emitted by the engine.
+const (
+	SeverityDebug   = "debug"
+	SeverityInfo    = "info"
+	SeverityInfoErr = "info#err"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// Events are the engine events emitted during a single operation, in the order they were received.
+type Events []events.EngineEvent
+
+// Steps returns the step metadata of every resource operation started, in order.
+func (e Events) Steps() []apitype.StepEventMetadata {
+	var steps []apitype.StepEventMetadata
+	for _, event := range e {
+		if event.ResourcePreEvent != nil {
+			steps = append(steps, event.ResourcePreEvent.Metadata)
+		}
+	}
+	return steps
+}
+
+// StepsByURN returns the steps started for each resource URN.
+// A resource may have several steps, such as for a replacement.
+func (e Events) StepsByURN() map[string][]apitype.StepEventMetadata {
+	byURN := map[string][]apitype.StepEventMetadata{}
+	for _, step := range e.Steps() {
+		byURN[step.URN] = append(byURN[step.URN], step)
+	}
+	return byURN
+}
+
+// StepsForURN returns the steps started for the given resource URN.
+func (e Events) StepsForURN(urn string) []apitype.StepEventMetadata {
+	var steps []apitype.StepEventMetadata
+	for _, step := range e.Steps() {
+		if step.URN == urn {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+// StepsWithOp returns the steps with any of the given operations.
+func (e Events) StepsWithOp(ops ...apitype.OpType) []apitype.StepEventMetadata {
+	var steps []apitype.StepEventMetadata
+	for _, step := range e.Steps() {
+		for _, op := range ops {
+			if step.Op == op {
+				steps = append(steps, step)
+				break
+			}
+		}
+	}
+	return steps
+}
+
+// Diagnostics returns all diagnostic events, excluding ephemeral diagnostics.
+func (e Events) Diagnostics() []apitype.DiagnosticEvent {
+	var diagnostics []apitype.DiagnosticEvent
+	for _, event := range e {
+		if event.DiagnosticEvent != nil && !event.DiagnosticEvent.Ephemeral {
+			diagnostics = append(diagnostics, *event.DiagnosticEvent)
+		}
+	}
+	return diagnostics
+}
+
+// DiagnosticsWithSeverity returns the diagnostics with any of the given severities.
+func (e Events) DiagnosticsWithSeverity(severities ...string) []apitype.DiagnosticEvent {
+	var diagnostics []apitype.DiagnosticEvent
+	for _, diagnostic := range e.Diagnostics() {
+		for _, severity := range severities {
+			if diagnostic.Severity == severity {
+				diagnostics = append(diagnostics, diagnostic)
+				break
+			}
+		}
+	}
+	return diagnostics
+}
+
+// DiagnosticsForURN returns the diagnostics reported for the given resource URN.
+func (e Events) DiagnosticsForURN(urn string) []apitype.DiagnosticEvent {
+	var diagnostics []apitype.DiagnosticEvent
+	for _, diagnostic := range e.Diagnostics() {
+		if diagnostic.URN == urn {
+			diagnostics = append(diagnostics, diagnostic)
+		}
+	}
+	return diagnostics
+}
+
+// ResourceOutputs returns the events emitted when each resource operation completed, including the resource outputs.
+func (e Events) ResourceOutputs() []apitype.ResOutputsEvent {
+	var outputs []apitype.ResOutputsEvent
+	for _, event := range e {
+		if event.ResOutputsEvent != nil {
+			outputs = append(outputs, *event.ResOutputsEvent)
+		}
+	}
+	return outputs
+}
+
+// ResourceOutputsForURN returns the last completed operation for the given resource URN, or nil if there's none.
+func (e Events) ResourceOutputsForURN(urn string) *apitype.ResOutputsEvent {
+	outputs := e.ResourceOutputs()
+	for i := len(outputs) - 1; i >= 0; i-- {
+		if outputs[i].Metadata.URN == urn {
+			return &outputs[i]
+		}
+	}
+	return nil
+}
+
+// FailedOperations returns the events emitted when a resource operation failed.
+func (e Events) FailedOperations() []apitype.ResOpFailedEvent {
+	var failed []apitype.ResOpFailedEvent
+	for _, event := range e {
+		if event.ResOpFailedEvent != nil {
+			failed = append(failed, *event.ResOpFailedEvent)
+		}
+	}
+	return failed
+}
+
+// PolicyViolations returns the policy violations reported by policy packs.
+func (e Events) PolicyViolations() []apitype.PolicyEvent {
+	var violations []apitype.PolicyEvent
+	for _, event := range e {
+		if event.PolicyEvent != nil {
+			violations = append(violations, *event.PolicyEvent)
+		}
+	}
+	return violations
+}
+
+// PolicyViolationsForURN returns the policy violations reported for the given resource URN.
+func (e Events) PolicyViolationsForURN(urn string) []apitype.PolicyEvent {
+	var violations []apitype.PolicyEvent
+	for _, violation := range e.PolicyViolations() {
+		if violation.ResourceURN == urn {
+			violations = append(violations, violation)
+		}
+	}
+	return violations
+}
+
+// Errors returns any errors encountered while receiving the event stream.
+func (e Events) Errors() []error {
+	var errs []error
+	for _, event := range e {
+		if event.Error != nil {
+			errs = append(errs, event.Error)
+		}
+	}
+	return errs
+}
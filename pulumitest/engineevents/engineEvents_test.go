@@ -0,0 +1,155 @@
+package engineevents_test
+
+import (
+	"testing"
+
+	"github.com/pulumi/providertest/pulumitest/engineevents"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	petURN      = "urn:pulumi:test::project::random:index/randomPet:RandomPet::pet"
+	passwordURN = "urn:pulumi:test::project::random:index/randomPassword:RandomPassword::password"
+)
+
+func testEvents() engineevents.Events {
+	event := func(e apitype.EngineEvent) events.EngineEvent {
+		return events.EngineEvent{EngineEvent: e}
+	}
+	petUpdate := apitype.StepEventMetadata{
+		Op:   apitype.OpUpdate,
+		URN:  petURN,
+		Type: "random:index/randomPet:RandomPet",
+		DetailedDiff: map[string]apitype.PropertyDiff{
+			"length": {Kind: apitype.DiffUpdate, InputDiff: true},
+		},
+	}
+	passwordReplace := apitype.StepEventMetadata{Op: apitype.OpReplace, URN: passwordURN}
+	return engineevents.Events{
+		event(apitype.EngineEvent{PreludeEvent: &apitype.PreludeEvent{}}),
+		event(apitype.EngineEvent{ResourcePreEvent: &apitype.ResourcePreEvent{Metadata: petUpdate}}),
+		event(apitype.EngineEvent{DiagnosticEvent: &apitype.DiagnosticEvent{
+			URN: petURN, Message: "length is deprecated", Severity: engineevents.SeverityWarning,
+		}}),
+		event(apitype.EngineEvent{DiagnosticEvent: &apitype.DiagnosticEvent{
+			Message: "progress", Severity: engineevents.SeverityInfo, Ephemeral: true,
+		}}),
+		event(apitype.EngineEvent{ResOutputsEvent: &apitype.ResOutputsEvent{Metadata: petUpdate}}),
+		event(apitype.EngineEvent{ResourcePreEvent: &apitype.ResourcePreEvent{
+			Metadata: apitype.StepEventMetadata{Op: apitype.OpCreateReplacement, URN: passwordURN},
+		}}),
+		event(apitype.EngineEvent{ResourcePreEvent: &apitype.ResourcePreEvent{Metadata: passwordReplace}}),
+		event(apitype.EngineEvent{ResOpFailedEvent: &apitype.ResOpFailedEvent{Metadata: passwordReplace}}),
+		event(apitype.EngineEvent{DiagnosticEvent: &apitype.DiagnosticEvent{
+			URN: passwordURN, Message: "replace failed", Severity: engineevents.SeverityError,
+		}}),
+		event(apitype.EngineEvent{PolicyEvent: &apitype.PolicyEvent{
+			ResourceURN: passwordURN, PolicyName: "min-length", EnforcementLevel: "mandatory",
+		}}),
+	}
+}
+
+func TestSteps(t *testing.T) {
+	t.Parallel()
+	e := testEvents()
+
+	assert.Len(t, e.Steps(), 3)
+	byURN := e.StepsByURN()
+	assert.Len(t, byURN[petURN], 1)
+	assert.Len(t, byURN[passwordURN], 2)
+
+	petSteps := e.StepsForURN(petURN)
+	assert.Equal(t, apitype.OpUpdate, petSteps[0].Op)
+	assert.Equal(t, apitype.DiffUpdate, petSteps[0].DetailedDiff["length"].Kind)
+
+	replacements := e.StepsWithOp(apitype.OpReplace, apitype.OpCreateReplacement)
+	assert.Len(t, replacements, 2)
+}
+
+func TestDiagnostics(t *testing.T) {
+	t.Parallel()
+	e := testEvents()
+
+	assert.Len(t, e.Diagnostics(), 2, "ephemeral diagnostics are excluded")
+	warnings := e.DiagnosticsWithSeverity(engineevents.SeverityWarning)
+	assert.Len(t, warnings, 1)
+	assert.Equal(t, "length is deprecated", warnings[0].Message)
+	assert.Len(t, e.DiagnosticsWithSeverity(engineevents.SeverityWarning, engineevents.SeverityError), 2)
+	assert.Len(t, e.DiagnosticsForURN(passwordURN), 1)
+}
+
+func TestResourceOutputsAndPolicies(t *testing.T) {
+	t.Parallel()
+	e := testEvents()
+
+	assert.Len(t, e.ResourceOutputs(), 1)
+	assert.NotNil(t, e.ResourceOutputsForURN(petURN))
+	assert.Nil(t, e.ResourceOutputsForURN(passwordURN))
+	assert.Len(t, e.FailedOperations(), 1)
+
+	violations := e.PolicyViolationsForURN(passwordURN)
+	assert.Len(t, violations, 1)
+	assert.Equal(t, "min-length", violations[0].PolicyName)
+	assert.Empty(t, e.PolicyViolationsForURN(petURN))
+	assert.Empty(t, e.Errors())
+}
+
+func TestChangedSteps(t *testing.T) {
+	t.Parallel()
+	event := func(e apitype.EngineEvent) events.EngineEvent {
+		return events.EngineEvent{EngineEvent: e}
+	}
+	state := func(urn, length string) *apitype.StepEventStateMetadata {
+		return &apitype.StepEventStateMetadata{URN: urn, Outputs: map[string]any{"length": length}}
+	}
+	driftURN := "urn:pulumi:test::project::random:index/randomPet:RandomPet::drift"
+	e := engineevents.Events{
+		event(apitype.EngineEvent{ResourcePreEvent: &apitype.ResourcePreEvent{
+			Metadata: apitype.StepEventMetadata{Op: apitype.OpSame, URN: petURN},
+		}}),
+		event(apitype.EngineEvent{ResourcePreEvent: &apitype.ResourcePreEvent{
+			Metadata: apitype.StepEventMetadata{Op: apitype.OpRefresh, URN: passwordURN},
+		}}),
+		event(apitype.EngineEvent{ResOutputsEvent: &apitype.ResOutputsEvent{
+			Metadata: apitype.StepEventMetadata{
+				Op: apitype.OpRefresh, URN: passwordURN, Old: state(passwordURN, "8"), New: state(passwordURN, "8"),
+			},
+		}}),
+		event(apitype.EngineEvent{ResourcePreEvent: &apitype.ResourcePreEvent{
+			Metadata: apitype.StepEventMetadata{Op: apitype.OpRefresh, URN: driftURN, Type: "random:index/randomPet:RandomPet"},
+		}}),
+		event(apitype.EngineEvent{ResOutputsEvent: &apitype.ResOutputsEvent{
+			Metadata: apitype.StepEventMetadata{
+				Op: apitype.OpRefresh, URN: driftURN, Type: "random:index/randomPet:RandomPet",
+				Old: state(driftURN, "2"), New: state(driftURN, "3"),
+			},
+		}}),
+	}
+
+	changed := e.ChangedSteps()
+	if assert.Len(t, changed, 1, "only the refresh which found a difference is a change") {
+		assert.Equal(t, driftURN, changed[0].URN)
+	}
+	assert.Empty(t, e.ChangedSteps("random:index/randomPet:RandomPet"))
+
+	assert.Len(t, testEvents().ChangedSteps(), 3)
+}
+
+func TestFormatSteps(t *testing.T) {
+	t.Parallel()
+	formatted := engineevents.FormatSteps([]apitype.StepEventMetadata{
+		{
+			Op:  apitype.OpUpdate,
+			URN: petURN,
+			DetailedDiff: map[string]apitype.PropertyDiff{
+				"prefix": {Kind: apitype.DiffAdd},
+				"length": {Kind: apitype.DiffUpdate},
+			},
+		},
+		{Op: apitype.OpUpdate, URN: passwordURN, Diffs: []string{"special"}},
+	})
+	assert.Equal(t, "update: "+petURN+"\n    length: update\n    prefix: add\n"+
+		"update: "+passwordURN+"\n    special\n", formatted)
+}
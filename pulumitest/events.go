@@ -0,0 +1,135 @@
+package pulumitest
+
+import (
+	"time"
+
+	"github.com/pulumi/providertest/pulumitest/engineevents"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optdestroy"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optpreview"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optrefresh"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optup"
+)
+
+// eventStreamTimeout is how long to wait for the event stream to be closed after an operation has completed.
+// The automation API only closes the stream once the CLI has connected to it, which might not happen if the
+// operation fails early.
+const eventStreamTimeout = 5 * time.Second
+
+// Events returns the engine events captured during the last Preview, Up, Refresh or Destroy operation.
+func (pt *PulumiTest) Events() engineevents.Events {
+	return pt.lastEvents
+}
+
+// eventCapture collects the engine events sent to its channel during an operation.
+type eventCapture struct {
+	ch     chan events.EngineEvent
+	done   chan struct{}
+	events engineevents.Events
+}
+
+// newEventCapture starts collecting events, forwarding each event to the given streams.
+func newEventCapture(forward ...chan<- events.EngineEvent) *eventCapture {
+	c := &eventCapture{
+		ch:   make(chan events.EngineEvent),
+		done: make(chan struct{}),
+	}
+	go func() {
+		for e := range c.ch {
+			c.events = append(c.events, e)
+			for _, stream := range forward {
+				stream <- e
+			}
+		}
+		close(c.done)
+	}()
+	return c
+}
+
+// wait returns the captured events once the operation has completed.
+// If the operation was never started, the channel was never passed to the automation API so is closed here.
+func (c *eventCapture) wait(t PT, started bool) engineevents.Events {
+	t.Helper()
+	if !started {
+		close(c.ch)
+	}
+	select {
+	case <-c.done:
+		return c.events
+	case <-time.After(eventStreamTimeout):
+		ptLogF(t, "timed out waiting for engine events")
+		return nil
+	}
+}
+
+// eventForwarder forwards the events captured by each attempt of a retried operation to the event streams passed by
+// the test. The automation API closes every stream passed to an operation once it completes, so the test's streams
+// can't be passed to each attempt. Instead they're closed once, after the last attempt.
+type eventForwarder struct {
+	streams  []chan<- events.EngineEvent
+	captures []*eventCapture
+}
+
+func newEventForwarder(streams []chan<- events.EngineEvent) *eventForwarder {
+	return &eventForwarder{streams: streams}
+}
+
+// capture returns a new capture for an attempt, which forwards its events to the test's streams.
+func (f *eventForwarder) capture() *eventCapture {
+	c := newEventCapture(f.streams...)
+	f.captures = append(f.captures, c)
+	return c
+}
+
+// close closes the test's streams once the events of every attempt have been forwarded.
+func (f *eventForwarder) close() {
+	if len(f.streams) == 0 {
+		return
+	}
+	closeStreams := func() {
+		for _, c := range f.captures {
+			<-c.done
+		}
+		for _, stream := range f.streams {
+			close(stream)
+		}
+	}
+	for _, c := range f.captures {
+		select {
+		case <-c.done:
+		default:
+			// An attempt timed out waiting for its events, so close the streams once they've been forwarded.
+			go closeStreams()
+			return
+		}
+	}
+	closeStreams()
+}
+
+// The automation API's EventStreams options replace any previously set streams, so the preview option appends the
+// capture channel instead. This allows tests to still pass their own event streams to a preview.
+type previewEventCapture chan<- events.EngineEvent
+
+func (c previewEventCapture) ApplyOption(o *optpreview.Options) {
+	o.EventStreams = append(o.EventStreams, c)
+}
+
+// Operations which can be retried only pass the capture channel to the automation API, replacing the test's own
+// event streams which instead receive the events from an eventForwarder.
+type upEventCapture chan<- events.EngineEvent
+
+func (c upEventCapture) ApplyOption(o *optup.Options) {
+	o.EventStreams = []chan<- events.EngineEvent{c}
+}
+
+type refreshEventCapture chan<- events.EngineEvent
+
+func (c refreshEventCapture) ApplyOption(o *optrefresh.Options) {
+	o.EventStreams = []chan<- events.EngineEvent{c}
+}
+
+type destroyEventCapture chan<- events.EngineEvent
+
+func (c destroyEventCapture) ApplyOption(o *optdestroy.Options) {
+	o.EventStreams = []chan<- events.EngineEvent{c}
+}
@@ -0,0 +1,41 @@
+package pulumitest_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/providertest/pulumitest"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optpreview"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngineEventsCaptured(t *testing.T) {
+	t.Parallel()
+	test := pulumitest.NewPulumiTest(t, filepath.Join("testdata", "yaml_program"))
+
+	// Event streams passed by the test still receive events.
+	ownEvents := make(chan events.EngineEvent)
+	ownEventCount := make(chan int)
+	go func() {
+		count := 0
+		for range ownEvents {
+			count++
+		}
+		ownEventCount <- count
+	}()
+	test.Preview(t, optpreview.EventStreams(ownEvents))
+	assert.Len(t, test.Events(), <-ownEventCount)
+	assert.NotEmpty(t, test.Events().StepsWithOp(apitype.OpCreate))
+
+	test.Up(t)
+	outputs := test.Events().ResourceOutputs()
+	assert.NotEmpty(t, outputs)
+	for _, output := range outputs {
+		assert.Equal(t, apitype.OpCreate, output.Metadata.Op, output.Metadata.URN)
+	}
+
+	test.Destroy(t)
+	assert.NotEmpty(t, test.Events().StepsWithOp(apitype.OpDelete))
+}
@@ -14,10 +14,15 @@ type cmdOutput struct {
 }
 
 func (pt *PulumiTest) execCmd(t PT, args ...string) cmdOutput {
+	t.Helper()
+	return pt.execCmdInDir(t, pt.CurrentStack().Workspace().WorkDir(), args...)
+}
+
+// execCmdInDir runs a pulumi command with the current stack's environment from the given directory.
+func (pt *PulumiTest) execCmdInDir(t PT, workdir string, args ...string) cmdOutput {
 	t.Helper()
 	workspace := pt.CurrentStack().Workspace()
 	ctx := context.Background()
-	workdir := workspace.WorkDir()
 	var env []string
 	for k, v := range workspace.GetEnvVars() {
 		env = append(env, fmt.Sprintf("%s=%s", k, v))
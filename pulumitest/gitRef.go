@@ -0,0 +1,172 @@
+package pulumitest
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pulumi/providertest/pulumitest/opttest"
+)
+
+// NewPulumiTestFromGitRef creates a new PulumiTest using the program source as it was at the given git ref, such as a
+// tag, branch or commit, in the local git repository containing the source directory.
+// The program is exported directly into a temporary directory, so the TestInPlace option is ignored.
+// This avoids keeping copies of previous versions of a program in testdata, for example when recording a baseline for
+// an upgrade test.
+func NewPulumiTestFromGitRef(t PT, source, ref string, opts ...opttest.Option) *PulumiTest {
+	t.Helper()
+	ctx := TestContext(t)
+	options := opttest.DefaultOptions()
+	for _, opt := range opts {
+		opt.Apply(options)
+	}
+	if options.PulumiHome == "" {
+		options.PulumiHome = isolatedPulumiHome(t)
+	}
+
+	tempDir := tempDirWithoutCleanupOnFailedTest(t, "programDir", options.TempDir)
+	// Maintain the directory name in the temp dir as this might be used for stack naming.
+	absSource, err := filepath.Abs(source)
+	if err != nil {
+		ptFatal(t, err)
+	}
+	destination := filepath.Join(tempDir, filepath.Base(absSource))
+	if err := os.Mkdir(destination, 0755); err != nil {
+		ptFatal(t, err)
+	}
+	ptLogF(t, "exporting %s at git ref %s", source, ref)
+	if err := exportGitTree(absSource, ref, destination); err != nil {
+		ptFatalF(t, "failed to export %s at git ref %s: %v", source, ref, err)
+	}
+
+	pt := &PulumiTest{
+		ctx:        ctx,
+		workingDir: destination,
+		options:    options,
+	}
+	pulumiTestInit(t, pt, options)
+	return pt
+}
+
+// UpdateSourceFromGitRef copies files from a source directory, as it was at the given git ref, to the current program
+// directory. Like UpdateSource, any files in the current program directory that are not in the source remain unchanged.
+func (pt *PulumiTest) UpdateSourceFromGitRef(t PT, ref string, pathElems ...string) {
+	t.Helper()
+
+	path := filepath.Join(pathElems...)
+	ptLogF(t, "updating source from %s at git ref %s", path, ref)
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		ptFatal(t, err)
+	}
+	if err := exportGitTree(absPath, ref, pt.workingDir); err != nil {
+		ptFatalF(t, "failed to export %s at git ref %s: %v", path, ref, err)
+	}
+}
+
+// exportGitTree writes the files in the source directory at the given git ref to the destination directory.
+// The source directory must be within a git repository.
+func exportGitTree(source, ref, destination string) error {
+	// Refs can't start with a dash, so this prevents the ref being parsed as an option.
+	if ref == "" || strings.HasPrefix(ref, "-") {
+		return fmt.Errorf("invalid git ref %q", ref)
+	}
+	root, err := gitOutput(source, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return err
+	}
+	prefix, err := gitOutput(source, "rev-parse", "--show-prefix")
+	if err != nil {
+		return err
+	}
+	// Resolve the ref to a tree first so only the resolved hash is passed to git archive.
+	tree, err := gitOutput(source, "rev-parse", "--verify", "--quiet", ref+"^{tree}")
+	if err != nil {
+		return fmt.Errorf("git ref %q not found: %w", ref, err)
+	}
+	archive, err := gitOutput(strings.TrimSpace(root), "archive", "--format=tar", strings.TrimSpace(tree)+":"+strings.TrimSpace(prefix))
+	if err != nil {
+		return err
+	}
+	return extractTar(bytes.NewReader([]byte(archive)), destination)
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	return string(out), nil
+}
+
+func extractTar(r io.Reader, destination string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(destination, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(target, filepath.Clean(destination)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path in archive: %s", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := createIfNotExists(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if !symlinkWithin(destination, target, header.Linkname) {
+				return fmt.Errorf("symlink %s points outside of the destination: %s", header.Name, header.Linkname)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(header.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(file, tr)
+			closeErr := file.Close()
+			if err != nil {
+				return err
+			}
+			if closeErr != nil {
+				return closeErr
+			}
+		}
+	}
+}
+
+// symlinkWithin returns true if the symlink at path, pointing to linkname, resolves to a location within the
+// destination directory.
+func symlinkWithin(destination, path, linkname string) bool {
+	if filepath.IsAbs(linkname) {
+		return false
+	}
+	resolved := filepath.Join(filepath.Dir(path), filepath.FromSlash(linkname))
+	destination = filepath.Clean(destination)
+	return resolved == destination || strings.HasPrefix(resolved, destination+string(os.PathSeparator))
+}
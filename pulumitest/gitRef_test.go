@@ -0,0 +1,99 @@
+package pulumitest_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/providertest/pulumitest"
+	"github.com/pulumi/providertest/pulumitest/opttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPulumiTestFromGitRef(t *testing.T) {
+	t.Parallel()
+	repo := t.TempDir()
+	programDir := filepath.Join(repo, "programs", "my_program")
+	require.NoError(t, os.MkdirAll(programDir, 0755))
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "--quiet")
+	require.NoError(t, os.WriteFile(filepath.Join(programDir, "Pulumi.yaml"), []byte("name: v1\n"), 0644))
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "v1")
+	git("tag", "v1")
+	require.NoError(t, os.WriteFile(filepath.Join(programDir, "Pulumi.yaml"), []byte("name: v2\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(programDir, "extra.txt"), []byte("new"), 0644))
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "v2")
+
+	test := pulumitest.NewPulumiTestFromGitRef(t, programDir, "v1", opttest.SkipInstall(), opttest.SkipStackCreate())
+	assert.Equal(t, "my_program", filepath.Base(test.WorkingDir()))
+	assert.NotEqual(t, programDir, test.WorkingDir())
+	content, err := os.ReadFile(filepath.Join(test.WorkingDir(), "Pulumi.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "name: v1\n", string(content))
+	assert.NoFileExists(t, filepath.Join(test.WorkingDir(), "extra.txt"))
+
+	test.UpdateSourceFromGitRef(t, "HEAD", programDir)
+	content, err = os.ReadFile(filepath.Join(test.WorkingDir(), "Pulumi.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "name: v2\n", string(content))
+	assert.FileExists(t, filepath.Join(test.WorkingDir(), "extra.txt"))
+}
+
+func TestNewPulumiTestFromGitRefError(t *testing.T) {
+	t.Parallel()
+	tt := &mockT{T: t}
+	pulumitest.NewPulumiTestFromGitRef(tt, "testdata/yaml_program", "this-ref-does-not-exist",
+		opttest.SkipInstall(), opttest.SkipStackCreate())
+	assert.True(t, tt.Failed())
+}
+
+func TestNewPulumiTestFromGitRefOptionRef(t *testing.T) {
+	t.Parallel()
+	outputPath := filepath.Join(t.TempDir(), "output.tar")
+	tt := &mockT{T: t}
+	pulumitest.NewPulumiTestFromGitRef(tt, "testdata/yaml_program", "--output="+outputPath,
+		opttest.SkipInstall(), opttest.SkipStackCreate())
+	assert.True(t, tt.Failed())
+	assert.NoFileExists(t, outputPath)
+}
+
+func TestNewPulumiTestFromGitRefSymlinks(t *testing.T) {
+	t.Parallel()
+	repo := t.TempDir()
+	programDir := filepath.Join(repo, "program")
+	require.NoError(t, os.MkdirAll(filepath.Join(programDir, "sub"), 0755))
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "--quiet")
+	require.NoError(t, os.WriteFile(filepath.Join(programDir, "Pulumi.yaml"), []byte("name: test\n"), 0644))
+	require.NoError(t, os.Symlink("../Pulumi.yaml", filepath.Join(programDir, "sub", "inside.yaml")))
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "inside")
+	git("tag", "inside")
+	require.NoError(t, os.Symlink("../../outside", filepath.Join(programDir, "sub", "escape")))
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "escape")
+	git("tag", "escape")
+
+	test := pulumitest.NewPulumiTestFromGitRef(t, programDir, "inside", opttest.SkipInstall(), opttest.SkipStackCreate())
+	content, err := os.ReadFile(filepath.Join(test.WorkingDir(), "sub", "inside.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "name: test\n", string(content))
+
+	tt := &mockT{T: t}
+	pulumitest.NewPulumiTestFromGitRef(tt, programDir, "escape", opttest.SkipInstall(), opttest.SkipStackCreate())
+	assert.True(t, tt.Failed())
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 
 	"github.com/pulumi/providertest/grpclog"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
 )
 
 // GrpcLog reads the gRPC log for the current stack based on the PULUMI_DEBUG_GRPC env var.
@@ -31,10 +32,20 @@ func (pt *PulumiTest) GrpcLog(t PT) *grpclog.GrpcLog {
 // ClearGrpcLog clears the gRPC log for the current stack based on the PULUMI_DEBUG_GRPC env var.
 func (pt *PulumiTest) ClearGrpcLog(t PT) {
 	t.Helper()
-	env := pt.CurrentStack().Workspace().GetEnvVars()
+	pt.clearGrpcLog(t, pt.CurrentStack())
+}
+
+func (pt *PulumiTest) clearGrpcLog(t PT, stack *auto.Stack) {
+	t.Helper()
+	env := stack.Workspace().GetEnvVars()
 	if env == nil || env["PULUMI_DEBUG_GRPC"] == "" {
 		return
 	}
+	if pt.grpcLogHistoryStacks[stack.Name()] {
+		if err := retainGrpcLogHistory(env["PULUMI_DEBUG_GRPC"]); err != nil {
+			ptFatalF(t, "failed to retain gRPC log history: %s", err)
+		}
+	}
 	if err := os.RemoveAll(env["PULUMI_DEBUG_GRPC"]); err != nil {
 		ptFatalF(t, "failed to clear gRPC log: %s", err)
 	}
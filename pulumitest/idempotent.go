@@ -0,0 +1,79 @@
+package pulumitest
+
+import (
+	"github.com/pulumi/providertest/pulumitest/changesummary"
+	"github.com/pulumi/providertest/pulumitest/engineevents"
+	"github.com/pulumi/providertest/pulumitest/optidempotent"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+)
+
+// AssertIdempotent deploys the current stack then checks that the deployment is stable: a preview, a second up and a
+// refresh must all report no changes.
+// Each phase which reports changes fails the test with the resources involved and their detailed diffs. Phases can be
+// skipped and noisy resource types tolerated using optidempotent options.
+// Returns the result of the initial up.
+func (pt *PulumiTest) AssertIdempotent(t PT, opts ...optidempotent.Option) auto.UpResult {
+	t.Helper()
+
+	options := optidempotent.Defaults()
+	for _, opt := range opts {
+		opt.Apply(&options)
+	}
+
+	result := pt.Up(t)
+	if !options.SkipPreview {
+		preview := pt.Preview(t)
+		pt.assertNoChangedSteps(t, "preview after up", changesummary.ChangeSummary(preview.ChangeSummary),
+			options.IgnoredResourceTypes)
+	}
+	if !options.SkipReUp {
+		up := pt.Up(t)
+		pt.assertNoChangedSteps(t, "second up", resourceChanges(up.Summary), options.IgnoredResourceTypes)
+	}
+	if !options.SkipRefresh {
+		refresh := pt.Refresh(t)
+		pt.assertNoChangedSteps(t, "refresh", resourceChanges(refresh.Summary), options.IgnoredResourceTypes)
+	}
+	return result
+}
+
+// assertNoChangedSteps fails the test if the events of the last operation contain any changed resources.
+// If no events were captured, the operation's change summary is checked instead. The summary doesn't include the
+// resource types, so changes can then only be tolerated if no resource types are ignored.
+func (pt *PulumiTest) assertNoChangedSteps(
+	t PT, phase string, summary changesummary.ChangeSummary, ignoredTypes []string,
+) {
+	t.Helper()
+
+	events := pt.Events()
+	if events == nil {
+		changes := summary.WhereOpNotEquals(apitype.OpSame)
+		switch {
+		case summary == nil:
+			ptErrorF(t, "no engine events or change summary were captured for %s, so its changes could not be checked",
+				phase)
+		case len(*changes) == 0:
+		case len(ignoredTypes) > 0:
+			ptErrorF(t, "no engine events were captured for %s, so its changes could not be checked against the "+
+				"ignored resource types: %v", phase, changes)
+		default:
+			ptErrorF(t, "expected %s to have no changes, got %v", phase, changes)
+		}
+		return
+	}
+
+	changed := events.ChangedSteps(ignoredTypes...)
+	if len(changed) > 0 {
+		ptErrorF(t, "expected %s to have no changes, got %d changed resources:\n%s",
+			phase, len(changed), engineevents.FormatSteps(changed))
+	}
+}
+
+// resourceChanges returns the change summary of an up or refresh, or nil if the summary has no resource changes.
+func resourceChanges(summary auto.UpdateSummary) changesummary.ChangeSummary {
+	if summary.ResourceChanges == nil {
+		return nil
+	}
+	return changesummary.FromStringIntMap(*summary.ResourceChanges)
+}
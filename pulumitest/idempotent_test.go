@@ -0,0 +1,39 @@
+package pulumitest_test
+
+import (
+	"testing"
+
+	"github.com/pulumi/providertest/pulumitest"
+	"github.com/pulumi/providertest/pulumitest/optidempotent"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssertIdempotent(t *testing.T) {
+	t.Parallel()
+	test := pulumitest.NewPulumiTest(t, "testdata/yaml_program")
+
+	result := test.AssertIdempotent(t)
+
+	assert.NotEmpty(t, result.Outputs["name"].Value)
+}
+
+func TestAssertIdempotentSkippingPhases(t *testing.T) {
+	t.Parallel()
+	test := pulumitest.NewPulumiTest(t, "testdata/yaml_program")
+
+	test.AssertIdempotent(t,
+		optidempotent.SkipReUp(),
+		optidempotent.SkipRefresh(),
+		optidempotent.IgnoreResourceTypes("random:index/randomPet:RandomPet"))
+}
+
+func TestAssertIdempotentFailsOnChanges(t *testing.T) {
+	t.Parallel()
+	// The program's commands are triggered by a random value which changes on every run.
+	test := pulumitest.NewPulumiTest(t, "testdata/yaml_command_invoke")
+
+	tt := &mockT{T: t}
+	test.AssertIdempotent(tt, optidempotent.SkipRefresh())
+
+	assert.True(t, tt.Failed())
+}
@@ -0,0 +1,251 @@
+package pulumitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pulumi/providertest/pulumitest/optimportbulk"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optimport"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// ImportSpec describes a resource to import with ImportBulk, as written to the `pulumi import --file` JSON.
+// Name is used to refer to the resource in the generated code and by other specs' Parent field. If set, LogicalName is
+// used as the resource's name in its URN instead.
+type ImportSpec = optimport.ImportResource
+
+// ImportBulkResult is the result of importing resources with ImportBulk.
+type ImportBulkResult struct {
+	// GeneratedCode is the program generated by `pulumi import` for the imported resources.
+	GeneratedCode string
+	// URNs of the imported resources, in the same order as the specs.
+	URNs []string
+	// Output is the stdout of the `pulumi import` command.
+	Output string
+	// GeneratedTest is the PulumiTest created from the generated code when using optimportbulk.ValidateGeneratedCode.
+	// It is nil if the code was generated in a language other than YAML, which is not validated.
+	GeneratedTest *PulumiTest
+}
+
+// ImportBulk imports several resources into the current stack in a single `pulumi import --file` operation and captures
+// the code generated for them.
+// Code is generated in the project's language unless another language is chosen with optimportbulk.Language.
+// The test fails if the import fails or any of the resources is missing from the stack afterwards.
+func (pt *PulumiTest) ImportBulk(t PT, resources []ImportSpec, opts ...optimportbulk.Option) ImportBulkResult {
+	t.Helper()
+
+	options := optimportbulk.Defaults()
+	for _, opt := range opts {
+		opt.Apply(&options)
+	}
+	stack := pt.currentStack
+	if stack == nil {
+		ptFatal(t, "no current stack")
+		return ImportBulkResult{}
+	}
+	projectSettings, err := stack.Workspace().ProjectSettings(pt.ctx)
+	if err != nil {
+		ptFatalF(t, "failed to get project settings: %s", err)
+		return ImportBulkResult{}
+	}
+	existing := map[string]bool{}
+	before, err := stateResourceURNs(pt.ExportStack(t).Deployment)
+	if err != nil {
+		ptFatalF(t, "failed to read stack state: %s", err)
+		return ImportBulkResult{}
+	}
+	for _, urn := range before {
+		existing[urn] = true
+	}
+
+	tempDir := t.TempDir()
+	importFilePath := filepath.Join(tempDir, "import.json")
+	if err := writeImportFile(importFilePath, resources, options.NameTable); err != nil {
+		ptFatalF(t, "failed to write import file: %s", err)
+		return ImportBulkResult{}
+	}
+
+	// `pulumi import` generates code in the language of the project's runtime, so to generate another language the
+	// import is run from a project with the same name but the chosen runtime.
+	importDir := stack.Workspace().WorkDir()
+	if options.Language != "" {
+		runtime, err := languageRuntime(options.Language)
+		if err != nil {
+			ptFatalF(t, "%s", err)
+			return ImportBulkResult{}
+		}
+		if projectSettings.Runtime.Name() != runtime.Name() || len(runtime.Options()) > 0 {
+			importDir = filepath.Join(tempDir, "project")
+			if err := writeImportProject(importDir, stack.Workspace().WorkDir(), projectSettings.Name.String(), runtime); err != nil {
+				ptFatalF(t, "failed to create %s project for import: %s", options.Language, err)
+				return ImportBulkResult{}
+			}
+		}
+	}
+
+	generatedCodePath := filepath.Join(tempDir, "generated_code.txt")
+	ptLogF(t, "importing %d resources", len(resources))
+	out, err := pt.importFile(t, stack, importDir, importFilePath, "--out", generatedCodePath)
+	if err != nil {
+		ptFatalF(t, "%s", err)
+		return ImportBulkResult{}
+	}
+	generatedCode, err := os.ReadFile(generatedCodePath)
+	if err != nil {
+		ptFatalF(t, "failed to read generated code: %s", err)
+		return ImportBulkResult{}
+	}
+	result := ImportBulkResult{
+		GeneratedCode: string(generatedCode),
+		Output:        out.Stdout,
+	}
+
+	after, err := stateResourceURNs(pt.ExportStack(t).Deployment)
+	if err != nil {
+		ptFatalF(t, "failed to read stack state: %s", err)
+		return ImportBulkResult{}
+	}
+	for _, resource := range resources {
+		urn := findImportedURN(after, existing, resource)
+		if urn == "" {
+			ptErrorF(t, "expected resource %s of type %s to be imported", resource.Name, resource.Type)
+			continue
+		}
+		result.URNs = append(result.URNs, urn)
+	}
+
+	if options.ValidateGeneratedCode {
+		result.GeneratedTest = pt.validateGeneratedCode(t, projectSettings.Runtime.Name(), options, result.GeneratedCode,
+			projectSettings.Name.String())
+	}
+	return result
+}
+
+// validateGeneratedCode creates a new PulumiTest from the generated YAML code and previews it.
+// Code generated in other languages isn't validated, so nil is returned.
+func (pt *PulumiTest) validateGeneratedCode(t PT, projectRuntime string, options optimportbulk.Options, code, projectName string) *PulumiTest {
+	t.Helper()
+
+	language := options.Language
+	if language == "" {
+		language = projectRuntime
+	}
+	if language != "yaml" {
+		ptLogF(t, "skipping validation of the generated code: only yaml is supported, not %s", language)
+		return nil
+	}
+
+	testOptions := pt.options.Copy()
+	for _, opt := range options.ValidateOpts {
+		opt.Apply(testOptions)
+	}
+	dir := filepath.Join(tempDirWithoutCleanupOnFailedTest(t, "importGenerated", testOptions.TempDir), "generated")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		ptFatal(t, err)
+		return nil
+	}
+	if !regexp.MustCompile(`(?m)^runtime:`).MatchString(code) {
+		code = fmt.Sprintf("name: %s-generated\nruntime: yaml\n%s", projectName, code)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "Pulumi.yaml"), []byte(code), 0o600); err != nil {
+		ptFatalF(t, "failed to write generated program: %s", err)
+		return nil
+	}
+
+	ptLogF(t, "previewing generated code in %s", dir)
+	generatedTest := &PulumiTest{
+		ctx:        pt.ctx,
+		workingDir: dir,
+		options:    testOptions,
+	}
+	pulumiTestInit(t, generatedTest, testOptions)
+	if _, err := generatedTest.PreviewErr(t); err != nil {
+		ptErrorF(t, "failed to preview generated code: %s\n%s", err, code)
+	}
+	return generatedTest
+}
+
+// languageRuntime returns the project runtime which generates code in the given language.
+func languageRuntime(language string) (workspace.ProjectRuntimeInfo, error) {
+	switch language {
+	case "yaml", "python", "go", "java":
+		return workspace.NewProjectRuntimeInfo(language, nil), nil
+	case "typescript", "nodejs":
+		return workspace.NewProjectRuntimeInfo("nodejs", nil), nil
+	case "javascript":
+		return workspace.NewProjectRuntimeInfo("nodejs", map[string]any{"typescript": false}), nil
+	case "csharp", "dotnet":
+		return workspace.NewProjectRuntimeInfo("dotnet", nil), nil
+	default:
+		return workspace.ProjectRuntimeInfo{}, fmt.Errorf("unsupported language for generated code: %s", language)
+	}
+}
+
+// writeImportProject creates a project with the given runtime and copies the stack config files into it, so that
+// `pulumi import` can be run from it against the same stack.
+func writeImportProject(dir, workDir, projectName string, runtime workspace.ProjectRuntimeInfo) error {
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return err
+	}
+	project := workspace.Project{
+		Name:    tokens.PackageName(projectName),
+		Runtime: runtime,
+	}
+	if err := project.Validate(); err != nil {
+		return err
+	}
+	if err := project.Save(filepath.Join(dir, "Pulumi.yaml")); err != nil {
+		return err
+	}
+	stackConfigs, err := filepath.Glob(filepath.Join(workDir, "Pulumi.*.yaml"))
+	if err != nil {
+		return err
+	}
+	for _, stackConfig := range stackConfigs {
+		if err := copy(stackConfig, filepath.Join(dir, filepath.Base(stackConfig))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findImportedURN returns the URN of the resource created by importing the spec, or an empty string if not found.
+func findImportedURN(urns []string, existing map[string]bool, resource ImportSpec) string {
+	name := resource.Name
+	if resource.LogicalName != "" {
+		name = resource.LogicalName
+	}
+	for _, urn := range urns {
+		if existing[urn] || urnName(urn) != name {
+			continue
+		}
+		// The URN's type is qualified by the types of the resource's parents.
+		typeEnd := strings.LastIndex(urn, "::")
+		typeStart := strings.LastIndex(urn[:typeEnd], "::")
+		qualifiedType := urn[typeStart+2 : typeEnd]
+		if qualifiedType == resource.Type || strings.HasSuffix(qualifiedType, "$"+resource.Type) {
+			return urn
+		}
+	}
+	return ""
+}
+
+// stateResourceURNs returns the URNs of all resources in the deployment.
+func stateResourceURNs(deployment json.RawMessage) ([]string, error) {
+	var state struct {
+		Resources []importStateResource `json:"resources"`
+	}
+	if err := json.Unmarshal(deployment, &state); err != nil {
+		return nil, err
+	}
+	urns := make([]string, 0, len(state.Resources))
+	for _, resource := range state.Resources {
+		urns = append(urns, resource.URN)
+	}
+	return urns, nil
+}
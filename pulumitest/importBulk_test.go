@@ -0,0 +1,97 @@
+package pulumitest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/providertest/pulumitest/optimportbulk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportBulk(t *testing.T) {
+	t.Parallel()
+	test := NewPulumiTest(t, "testdata/yaml_empty")
+
+	result := test.ImportBulk(t, []ImportSpec{
+		{Type: "random:index/randomString:RandomString", Name: "first", ID: "importedFirst"},
+		{Type: "random:index/randomString:RandomString", Name: "second", ID: "importedSecond"},
+	}, optimportbulk.ValidateGeneratedCode())
+
+	assert.Contains(t, result.GeneratedCode, "type: random:RandomString")
+	require.Len(t, result.URNs, 2)
+	assert.Equal(t, "first", urnName(result.URNs[0]))
+	assert.Equal(t, "second", urnName(result.URNs[1]))
+	assert.NotNil(t, result.GeneratedTest)
+}
+
+func TestImportBulkLanguage(t *testing.T) {
+	t.Parallel()
+	test := NewPulumiTest(t, "testdata/yaml_empty")
+
+	result := test.ImportBulk(t, []ImportSpec{
+		{Type: "random:index/randomString:RandomString", Name: "str", ID: "importedString"},
+	}, optimportbulk.Language("typescript"))
+
+	assert.Contains(t, result.GeneratedCode, "new random.RandomString")
+	assert.Len(t, result.URNs, 1)
+}
+
+func TestFindImportedURN(t *testing.T) {
+	t.Parallel()
+	const (
+		existingURN = "urn:pulumi:test::project::random:index/randomString:RandomString::existing"
+		parentURN   = "urn:pulumi:test::project::random:index/randomString:RandomString::parent"
+		childURN    = "urn:pulumi:test::project::random:index/randomString:RandomString$random:index/randomPet:RandomPet::child"
+	)
+	urns := []string{existingURN, parentURN, childURN}
+	existing := map[string]bool{existingURN: true}
+
+	assert.Equal(t, parentURN, findImportedURN(urns, existing,
+		ImportSpec{Type: "random:index/randomString:RandomString", Name: "parent"}))
+	assert.Equal(t, childURN, findImportedURN(urns, existing,
+		ImportSpec{Type: "random:index/randomPet:RandomPet", Name: "pet", LogicalName: "child"}))
+	assert.Empty(t, findImportedURN(urns, existing,
+		ImportSpec{Type: "random:index/randomString:RandomString", Name: "existing"}), "existing resources weren't imported")
+	assert.Empty(t, findImportedURN(urns, existing,
+		ImportSpec{Type: "random:index/randomPet:RandomPet", Name: "parent"}), "the type must match")
+}
+
+func TestWriteImportProject(t *testing.T) {
+	t.Parallel()
+	workDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "Pulumi.test.yaml"), []byte("config: {}\n"), 0o600))
+	dir := filepath.Join(t.TempDir(), "project")
+
+	runtime, err := languageRuntime("javascript")
+	require.NoError(t, err)
+	require.NoError(t, writeImportProject(dir, workDir, "my-project", runtime))
+
+	project, err := os.ReadFile(filepath.Join(dir, "Pulumi.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "name: my-project\nruntime:\n  name: nodejs\n  options:\n    typescript: false\n", string(project))
+	assert.FileExists(t, filepath.Join(dir, "Pulumi.test.yaml"))
+
+	runtime, err = languageRuntime("csharp")
+	require.NoError(t, err)
+	dotnetDir := filepath.Join(t.TempDir(), "project")
+	require.NoError(t, writeImportProject(dotnetDir, workDir, "my-project", runtime))
+	project, err = os.ReadFile(filepath.Join(dotnetDir, "Pulumi.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "name: my-project\nruntime: dotnet\n", string(project))
+
+	_, err = languageRuntime("cobol")
+	assert.ErrorContains(t, err, "unsupported language for generated code: cobol")
+}
+
+func TestValidateGeneratedCodeSkipsOtherLanguages(t *testing.T) {
+	t.Parallel()
+	tt := &failRecorder{T: t}
+	pt := &PulumiTest{}
+
+	generated := pt.validateGeneratedCode(tt, "yaml", optimportbulk.Options{Language: "typescript"}, "", "project")
+
+	assert.Nil(t, generated)
+	assert.False(t, tt.failed)
+}
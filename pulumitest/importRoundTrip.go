@@ -0,0 +1,293 @@
+package pulumitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/providertest/pulumitest/engineevents"
+	"github.com/pulumi/providertest/pulumitest/optnewstack"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optremove"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+)
+
+// importStateResource is the subset of a resource's state needed to import it.
+type importStateResource struct {
+	URN      string         `json:"urn"`
+	Type     string         `json:"type"`
+	ID       string         `json:"id"`
+	Custom   bool           `json:"custom"`
+	Delete   bool           `json:"delete"`
+	External bool           `json:"external"`
+	Parent   string         `json:"parent"`
+	Provider string         `json:"provider"`
+	Inputs   map[string]any `json:"inputs"`
+}
+
+// importableResources are the resources of a stack which can be imported into a new stack.
+type importableResources struct {
+	// Resources to write to the import file.
+	Resources []ImportSpec
+	// URNs maps the URN of each resource to its ID.
+	URNs map[string]string
+	// Skipped describes each custom resource which can't be imported.
+	Skipped []string
+}
+
+// AssertImportRoundTrip checks that every custom resource in the current stack can be imported and that the imported
+// state matches the program. This catches provider Read implementations which lose properties.
+// The current stack is exported and each custom resource is imported by its ID into a new, empty stack using
+// `pulumi import --file`. The test fails if the import fails, if any resource is missing from the imported state or if
+// a preview of the program against the imported state shows changes to any imported resource.
+// Resources which are children of components or use an explicit provider can't be imported and are skipped.
+// The imported stack is removed without destroying the resources, and the original stack is left as the current stack.
+// Returns the result of the preview against the imported state.
+func (pt *PulumiTest) AssertImportRoundTrip(t PT) auto.PreviewResult {
+	t.Helper()
+
+	original := pt.currentStack
+	if original == nil {
+		ptFatal(t, "no current stack")
+		return auto.PreviewResult{}
+	}
+	deployment := pt.ExportStack(t)
+	importable, err := findImportableResources(deployment.Deployment)
+	if err != nil {
+		ptFatalF(t, "failed to read resources to import: %s", err)
+		return auto.PreviewResult{}
+	}
+	for _, skipped := range importable.Skipped {
+		ptLogF(t, "skipping import of %s", skipped)
+	}
+	if len(importable.Resources) == 0 {
+		ptFatalF(t, "no custom resources to import in stack %q", original.Name())
+		return auto.PreviewResult{}
+	}
+	config, err := original.GetAllConfig(pt.ctx)
+	if err != nil {
+		ptFatalF(t, "failed to read config of stack %q: %s", original.Name(), err)
+		return auto.PreviewResult{}
+	}
+
+	stacks := pt.stacks
+	imported := pt.NewStack(t, original.Name()+"-import", optnewstack.DisableAutoDestroy())
+	if imported == nil {
+		return auto.PreviewResult{}
+	}
+	defer func() {
+		// The imported resources are the same as the original stack's, so only the stack's state is removed.
+		if err := imported.Workspace().RemoveStack(pt.ctx, imported.Name(), optremove.Force()); err != nil {
+			ptErrorF(t, "failed to remove imported stack %q: %s", imported.Name(), err)
+		}
+		pt.currentStack = original
+		pt.stacks = stacks
+	}()
+	if err := imported.SetAllConfig(pt.ctx, config); err != nil {
+		ptFatalF(t, "failed to copy config to stack %q: %s", imported.Name(), err)
+		return auto.PreviewResult{}
+	}
+
+	importFile := filepath.Join(t.TempDir(), "import.json")
+	if err := writeImportFile(importFile, importable.Resources, nil); err != nil {
+		ptFatalF(t, "failed to write import file: %s", err)
+		return auto.PreviewResult{}
+	}
+	ptLogF(t, "importing %d resources into stack %s", len(importable.Resources), imported.Name())
+	if _, err := pt.importFile(t, imported, imported.Workspace().WorkDir(), importFile, "--generate-code=false"); err != nil {
+		ptFatalF(t, "%s", err)
+		return auto.PreviewResult{}
+	}
+
+	expected := importedURNs(importable.URNs, imported.Name())
+	importedDeployment := pt.ExportStack(t)
+	missing, err := missingImportedResources(importedDeployment.Deployment, expected)
+	if err != nil {
+		ptFatalF(t, "failed to read imported state: %s", err)
+		return auto.PreviewResult{}
+	}
+	if len(missing) > 0 {
+		ptErrorF(t, "expected %d resources to be imported, missing:\n%s", len(expected), strings.Join(missing, "\n"))
+	}
+
+	result := pt.Preview(t)
+	if changed := importedChanges(pt.Events(), expected); len(changed) > 0 {
+		ptErrorF(t, "expected no changes after importing, got %d changed resources:\n%s",
+			len(changed), engineevents.FormatSteps(changed))
+	}
+	return result
+}
+
+// importFile runs `pulumi import --file` against the given stack, which must be the current stack, from the given
+// project directory.
+func (pt *PulumiTest) importFile(t PT, stack *auto.Stack, dir, path string, args ...string) (cmdOutput, error) {
+	t.Helper()
+
+	arguments := []string{"import", "--file", path, "--yes", "--protect=false", "-s", stack.Name()}
+	arguments = append(arguments, args...)
+	var ret cmdOutput
+	err := pt.withProviders(t, stack, func() error {
+		ret = pt.execCmdInDir(t, dir, arguments...)
+		if ret.ReturnCode != 0 {
+			return fmt.Errorf("failed to import resources from %s: %s", path, ret.Stderr)
+		}
+		return nil
+	})
+	if err != nil && ret.ReturnCode != 0 && ret.Stdout != "" {
+		t.Log(ret.Stdout)
+	}
+	return ret, err
+}
+
+// writeImportFile writes the resources and name table in the format expected by `pulumi import --file`.
+func writeImportFile(path string, resources []ImportSpec, nameTable map[string]string) error {
+	contents := map[string]any{
+		"resources": resources,
+	}
+	if len(nameTable) > 0 {
+		contents["nameTable"] = nameTable
+	}
+	contentsBytes, err := json.MarshalIndent(contents, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, contentsBytes, 0o600)
+}
+
+// findImportableResources returns the custom resources in the deployment which can be imported into an empty stack.
+// Each resource's parent must be the stack or another imported resource, and it must use a default provider.
+func findImportableResources(deployment json.RawMessage) (importableResources, error) {
+	var state struct {
+		Resources []importStateResource `json:"resources"`
+	}
+	if err := json.Unmarshal(deployment, &state); err != nil {
+		return importableResources{}, err
+	}
+
+	byURN := map[string]importStateResource{}
+	for _, resource := range state.Resources {
+		byURN[resource.URN] = resource
+	}
+
+	result := importableResources{URNs: map[string]string{}}
+	// Names must be unique in the import file, so the URN's name is only used if it's not already taken.
+	importNames := map[string]string{}
+	usedNames := map[string]bool{}
+	for _, resource := range state.Resources {
+		if !resource.Custom || resource.Delete || resource.External || resource.ID == "" || strings.HasPrefix(resource.Type, "pulumi:providers:") {
+			continue
+		}
+
+		var parentName string
+		if resource.Parent != "" && byURN[resource.Parent].Type != "pulumi:pulumi:Stack" {
+			name, ok := importNames[resource.Parent]
+			if !ok {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("%s: parent %s can't be imported", resource.URN, resource.Parent))
+				continue
+			}
+			parentName = name
+		}
+
+		var version string
+		if resource.Provider != "" {
+			providerURN := resource.Provider
+			if index := strings.LastIndex(providerURN, "::"); index >= 0 {
+				providerURN = providerURN[:index]
+			}
+			if !strings.HasPrefix(urnName(providerURN), "default") {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("%s: explicit provider %s can't be imported", resource.URN, providerURN))
+				continue
+			}
+			version, _ = byURN[providerURN].Inputs["version"].(string)
+		}
+
+		logicalName := urnName(resource.URN)
+		name := logicalName
+		for i := 2; usedNames[name]; i++ {
+			name = fmt.Sprintf("%s-%d", logicalName, i)
+		}
+		usedNames[name] = true
+		importNames[resource.URN] = name
+
+		importResource := ImportSpec{
+			Type:    resource.Type,
+			Name:    name,
+			ID:      resource.ID,
+			Parent:  parentName,
+			Version: version,
+		}
+		if name != logicalName {
+			importResource.LogicalName = logicalName
+		}
+		result.Resources = append(result.Resources, importResource)
+		result.URNs[resource.URN] = resource.ID
+	}
+	return result, nil
+}
+
+// importedURNs returns the URNs the resources are expected to have once imported into the given stack, mapped to
+// their IDs. URNs include the stack name, so the original URNs can't be compared directly.
+func importedURNs(urns map[string]string, stack string) map[string]string {
+	imported := map[string]string{}
+	for urn, id := range urns {
+		imported[urnWithStack(urn, stack)] = id
+	}
+	return imported
+}
+
+// importedChanges returns the changed steps of the expected imported resources.
+func importedChanges(events engineevents.Events, expected map[string]string) []apitype.StepEventMetadata {
+	var changed []apitype.StepEventMetadata
+	for _, step := range events.ChangedSteps() {
+		if _, ok := expected[step.URN]; ok {
+			changed = append(changed, step)
+		}
+	}
+	return changed
+}
+
+// missingImportedResources returns each expected URN which isn't in the deployment with the expected ID.
+func missingImportedResources(deployment json.RawMessage, expected map[string]string) ([]string, error) {
+	var state struct {
+		Resources []importStateResource `json:"resources"`
+	}
+	if err := json.Unmarshal(deployment, &state); err != nil {
+		return nil, err
+	}
+	actual := map[string]string{}
+	for _, resource := range state.Resources {
+		actual[resource.URN] = resource.ID
+	}
+	var missing []string
+	for urn, id := range expected {
+		if actualID, ok := actual[urn]; !ok || actualID != id {
+			missing = append(missing, fmt.Sprintf("%s (ID: %s)", urn, id))
+		}
+	}
+	sort.Strings(missing)
+	return missing, nil
+}
+
+// urnWithStack returns the URN with its stack name replaced.
+func urnWithStack(urn, stack string) string {
+	const prefix = "urn:pulumi:"
+	if !strings.HasPrefix(urn, prefix) {
+		return urn
+	}
+	index := strings.Index(urn[len(prefix):], "::")
+	if index < 0 {
+		return urn
+	}
+	return prefix + stack + urn[len(prefix)+index:]
+}
+
+// urnName returns the resource name from the end of a URN.
+func urnName(urn string) string {
+	if index := strings.LastIndex(urn, "::"); index >= 0 {
+		return urn[index+2:]
+	}
+	return urn
+}
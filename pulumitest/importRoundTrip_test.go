@@ -0,0 +1,122 @@
+package pulumitest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pulumi/providertest/pulumitest/engineevents"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssertImportRoundTrip(t *testing.T) {
+	t.Parallel()
+	test := NewPulumiTest(t, "testdata/yaml_import_round_trip")
+	test.Up(t)
+	original := test.CurrentStack()
+
+	test.AssertImportRoundTrip(t)
+
+	assert.Equal(t, original, test.CurrentStack(), "the original stack should remain current")
+	assert.Len(t, test.Stacks(), 1, "the imported stack should be removed")
+}
+
+func TestFindImportableResources(t *testing.T) {
+	t.Parallel()
+	const (
+		stackURN     = "urn:pulumi:test::project::pulumi:pulumi:Stack::project-test"
+		providerURN  = "urn:pulumi:test::project::pulumi:providers:random::default_4_18_4"
+		explicitURN  = "urn:pulumi:test::project::pulumi:providers:random::explicit"
+		componentURN = "urn:pulumi:test::project::my:index:Component::component"
+	)
+	deployment, err := json.Marshal(map[string]any{
+		"resources": []map[string]any{
+			{"urn": stackURN, "type": "pulumi:pulumi:Stack", "custom": false},
+			{"urn": providerURN, "type": "pulumi:providers:random", "custom": true, "id": "provider-id",
+				"parent": stackURN, "inputs": map[string]any{"version": "4.18.4"}},
+			{"urn": explicitURN, "type": "pulumi:providers:random", "custom": true, "id": "explicit-id", "parent": stackURN},
+			{"urn": "urn:pulumi:test::project::random:index/randomString:RandomString::str",
+				"type": "random:index/randomString:RandomString", "custom": true, "id": "abc",
+				"parent": stackURN, "provider": providerURN + "::provider-id"},
+			{"urn": "urn:pulumi:test::project::random:index/randomPassword:RandomPassword::str",
+				"type": "random:index/randomPassword:RandomPassword", "custom": true, "id": "def",
+				"parent": stackURN, "provider": providerURN + "::provider-id"},
+			{"urn": "urn:pulumi:test::project::random:index/randomString:RandomString$random:index/randomString:RandomString::child",
+				"type": "random:index/randomString:RandomString", "custom": true, "id": "ghi",
+				"parent":   "urn:pulumi:test::project::random:index/randomString:RandomString::str",
+				"provider": providerURN + "::provider-id"},
+			{"urn": "urn:pulumi:test::project::random:index/randomString:RandomString::explicit",
+				"type": "random:index/randomString:RandomString", "custom": true, "id": "jkl",
+				"parent": stackURN, "provider": explicitURN + "::explicit-id"},
+			{"urn": componentURN, "type": "my:index:Component", "parent": stackURN},
+			{"urn": "urn:pulumi:test::project::my:index:Component$random:index/randomString:RandomString::nested",
+				"type": "random:index/randomString:RandomString", "custom": true, "id": "mno",
+				"parent": componentURN, "provider": providerURN + "::provider-id"},
+			{"urn": "urn:pulumi:test::project::random:index/randomString:RandomString::deleted",
+				"type": "random:index/randomString:RandomString", "custom": true, "id": "pqr", "delete": true,
+				"parent": stackURN, "provider": providerURN + "::provider-id"},
+		},
+	})
+	require.NoError(t, err)
+
+	importable, err := findImportableResources(deployment)
+	require.NoError(t, err)
+
+	assert.Equal(t, []ImportSpec{
+		{Type: "random:index/randomString:RandomString", Name: "str", ID: "abc", Version: "4.18.4"},
+		{Type: "random:index/randomPassword:RandomPassword", Name: "str-2", LogicalName: "str", ID: "def", Version: "4.18.4"},
+		{Type: "random:index/randomString:RandomString", Name: "child", ID: "ghi", Parent: "str", Version: "4.18.4"},
+	}, importable.Resources)
+	assert.Len(t, importable.URNs, 3)
+	assert.Len(t, importable.Skipped, 2, "resources with explicit providers or component parents are skipped")
+}
+
+func TestMissingImportedResources(t *testing.T) {
+	t.Parallel()
+	deployment := json.RawMessage(`{"resources": [{"urn": "urn:a", "id": "1"}, {"urn": "urn:b", "id": "other"}]}`)
+
+	missing, err := missingImportedResources(deployment, map[string]string{"urn:a": "1", "urn:b": "2", "urn:c": "3"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"urn:b (ID: 2)", "urn:c (ID: 3)"}, missing)
+}
+
+func TestImportRoundTripAcrossStacks(t *testing.T) {
+	t.Parallel()
+	const (
+		originalURN = "urn:pulumi:test::project::random:index/randomString:RandomString::str"
+		importedURN = "urn:pulumi:test-import::project::random:index/randomString:RandomString::str"
+	)
+	original := json.RawMessage(`{"resources": [
+		{"urn": "urn:pulumi:test::project::pulumi:pulumi:Stack::project-test", "type": "pulumi:pulumi:Stack"},
+		{"urn": "` + originalURN + `", "type": "random:index/randomString:RandomString", "custom": true, "id": "abc"}
+	]}`)
+	importable, err := findImportableResources(original)
+	require.NoError(t, err)
+
+	expected := importedURNs(importable.URNs, "test-import")
+	assert.Equal(t, map[string]string{importedURN: "abc"}, expected)
+
+	imported := json.RawMessage(`{"resources": [{"urn": "` + importedURN + `", "id": "abc"}]}`)
+	missing, err := missingImportedResources(imported, expected)
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+
+	step := func(urn string) events.EngineEvent {
+		return events.EngineEvent{EngineEvent: apitype.EngineEvent{ResourcePreEvent: &apitype.ResourcePreEvent{
+			Metadata: apitype.StepEventMetadata{Op: apitype.OpUpdate, URN: urn},
+		}}}
+	}
+	changed := importedChanges(engineevents.Events{step(importedURN), step(originalURN)}, expected)
+	if assert.Len(t, changed, 1, "a lossy Read shows as a change to the imported resource") {
+		assert.Equal(t, importedURN, changed[0].URN)
+	}
+}
+
+func TestURNWithStack(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "urn:pulumi:other::project::random:index/randomPet:RandomPet::pet",
+		urnWithStack("urn:pulumi:test::project::random:index/randomPet:RandomPet::pet", "other"))
+	assert.Equal(t, "not-a-urn", urnWithStack("not-a-urn", "other"))
+}
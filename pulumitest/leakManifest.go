@@ -0,0 +1,106 @@
+package pulumitest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+)
+
+// LeakManifest records a stack which was not destroyed at the end of a test, so that it can be destroyed later by
+// SweepLeakedStacks.
+type LeakManifest struct {
+	// StackName is the name of the stack which was not destroyed.
+	StackName string `json:"stackName"`
+	// ProjectName is the name of the project the stack belongs to.
+	ProjectName string `json:"projectName,omitempty"`
+	// BackendURL is the backend holding the stack's state. If empty, the ambient backend was used.
+	BackendURL string `json:"backendURL,omitempty"`
+	// ConfigPassphrase is the passphrase used to encrypt the stack's secrets.
+	ConfigPassphrase string `json:"configPassphrase,omitempty"`
+	// WorkingDir is the program directory the stack was created in.
+	WorkingDir string `json:"workingDir"`
+	// TempDir is the temporary directory created for the test, which can be removed once the stack is destroyed.
+	TempDir string `json:"tempDir,omitempty"`
+	// AttachProviders are the names of providers which were attached to the test and so must be started to destroy
+	// the stack.
+	AttachProviders []string `json:"attachProviders,omitempty"`
+	// TestName is the name of the test which created the stack.
+	TestName string `json:"testName"`
+	// Reason describes why the stack was not destroyed.
+	Reason string `json:"reason"`
+	// CreatedAt is when the stack was left behind.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// LeakManifestDir returns the directory where leak manifests are written.
+// This is set by the PULUMITEST_LEAK_MANIFEST_DIR environment variable, or defaults to "pulumitest-leaks" in the
+// OS temporary directory.
+func LeakManifestDir() string {
+	if dir := os.Getenv("PULUMITEST_LEAK_MANIFEST_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "pulumitest-leaks")
+}
+
+// writeLeakManifest records that the stack was not destroyed so it can be swept later.
+// The test's temp directory, which may hold the stack's backend, is retained so the stack can still be destroyed.
+func (pt *PulumiTest) writeLeakManifest(t PT, stack *auto.Stack, env map[string]string, reason string) {
+	t.Helper()
+	manifest := LeakManifest{
+		StackName:        stack.Name(),
+		BackendURL:       env["PULUMI_BACKEND_URL"],
+		ConfigPassphrase: env["PULUMI_CONFIG_PASSPHRASE"],
+		WorkingDir:       stack.Workspace().WorkDir(),
+		TestName:         t.Name(),
+		Reason:           reason,
+		CreatedAt:        time.Now().UTC(),
+	}
+	if projectSettings, err := stack.Workspace().ProjectSettings(pt.ctx); err == nil {
+		manifest.ProjectName = projectSettings.Name.String()
+	}
+	if state, ok := tempDirStates.Load(t); ok {
+		tempDir := state.(*tempDirState)
+		if err := tempDir.retain(); err != nil {
+			ptLogF(t, "failed to mark temp directory %q as retained: %v", tempDir.tempDir, err)
+		}
+		manifest.TempDir = tempDir.tempDir
+	}
+	for name := range pt.options.ProviderFactories() {
+		manifest.AttachProviders = append(manifest.AttachProviders, string(name))
+	}
+	sort.Strings(manifest.AttachProviders)
+
+	path, err := writeLeakManifestFile(LeakManifestDir(), manifest)
+	if err != nil {
+		ptLogF(t, "failed to write leak manifest: %v", err)
+		return
+	}
+	ptLogF(t, "leaked stack %q recorded in %q, run SweepLeakedStacks to destroy it later", stack.Name(), path)
+}
+
+func writeLeakManifestFile(dir string, manifest LeakManifest) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	file, err := os.CreateTemp(dir, manifest.StackName+"-*.json")
+	if err != nil {
+		return "", err
+	}
+	_, err = file.Write(manifestBytes)
+	closeErr := file.Close()
+	if err != nil {
+		return "", err
+	}
+	if closeErr != nil {
+		return "", closeErr
+	}
+	return file.Name(), nil
+}
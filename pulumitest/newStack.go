@@ -21,6 +21,8 @@ import (
 
 // NewStack creates a new stack, ensure it's cleaned up after the test is done.
 // If no stack name is provided, a random one will be generated.
+// The new stack becomes the current stack. Stacks created by the same test share a temporary backend, so they can
+// reference each other using StackReference.
 func (pt *PulumiTest) NewStack(t PT, stackName string, opts ...optnewstack.NewStackOpt) *auto.Stack {
 	t.Helper()
 
@@ -48,9 +50,12 @@ func (pt *PulumiTest) NewStack(t PT, stackName string, opts ...optnewstack.NewSt
 	}
 
 	if !options.UseAmbientBackend {
-		backendFolder := tempDirWithoutCleanupOnFailedTest(t, "backendDir", options.TempDir)
-		t.Log("PULUMI_BACKEND_URL=" + "file://" + backendFolder)
-		env["PULUMI_BACKEND_URL"] = "file://" + backendFolder
+		if pt.backendURL == "" {
+			backendFolder := tempDirWithoutCleanupOnFailedTest(t, "backendDir", options.TempDir)
+			pt.backendURL = "file://" + backendFolder
+		}
+		t.Log("PULUMI_BACKEND_URL=" + pt.backendURL)
+		env["PULUMI_BACKEND_URL"] = pt.backendURL
 	}
 
 	if !options.DisableGrpcLog {
@@ -221,6 +226,12 @@ func (pt *PulumiTest) NewStack(t PT, stackName string, opts ...optnewstack.NewSt
 		ptFatalF(t, "failed to create stack: %s", err)
 		return nil
 	}
+	if stackOptions.VerifyDestroy {
+		if pt.grpcLogHistoryStacks == nil {
+			pt.grpcLogHistoryStacks = map[string]bool{}
+		}
+		pt.grpcLogHistoryStacks[stackName] = true
+	}
 	if !stackOptions.SkipDestroy {
 		t.Cleanup(func() {
 			t.Helper()
@@ -228,13 +239,19 @@ func (pt *PulumiTest) NewStack(t PT, stackName string, opts ...optnewstack.NewSt
 			if ptFailed(t) && skipDestroyOnFailure() {
 				t.Log("Skipping destroy because PULUMITEST_SKIP_DESTROY_ON_FAILURE is set to 'true'.")
 				writeDestroyScript(t, stack.Workspace().WorkDir(), stackName, env)
+				pt.writeLeakManifest(t, &stack, env, "destroy skipped because the test failed and PULUMITEST_SKIP_DESTROY_ON_FAILURE is set")
 				return
 			}
 
 			t.Log("destroying stack, to skip this set PULUMITEST_SKIP_DESTROY_ON_FAILURE=true")
-			err := pt.withProviders(t, &stack, func() error {
-				_, destroyErr := stack.Destroy(pt.ctx)
-				return destroyErr
+			_, err := withRetry(t, pt.ctx, "destroy", options.RetryPolicy, func() (auto.DestroyResult, error) {
+				var result auto.DestroyResult
+				err := pt.withProviders(t, &stack, func() error {
+					var destroyErr error
+					result, destroyErr = stack.Destroy(pt.ctx)
+					return destroyErr
+				})
+				return result, err
 			})
 			if err != nil {
 				if errors.Is(err, errStartProviders) {
@@ -243,8 +260,12 @@ func (pt *PulumiTest) NewStack(t PT, stackName string, opts ...optnewstack.NewSt
 					ptErrorF(t, "failed to destroy stack %q during cleanup; leaving stack state for manual cleanup: %s", stackName, err)
 				}
 				writeDestroyScript(t, stack.Workspace().WorkDir(), stackName, env)
+				pt.writeLeakManifest(t, &stack, env, fmt.Sprintf("cleanup destroy failed: %s", err))
 				return
 			}
+			if stackOptions.VerifyDestroy {
+				pt.verifyDestroyed(t, &stack)
+			}
 			err = stack.Workspace().RemoveStack(pt.ctx, stackName, optremove.Force())
 			if err != nil {
 				ptErrorF(t, "failed to remove stack: %s", err)
@@ -252,6 +273,7 @@ func (pt *PulumiTest) NewStack(t PT, stackName string, opts ...optnewstack.NewSt
 		})
 	}
 	pt.currentStack = &stack
+	pt.stacks = append(pt.stacks, &stack)
 	if !options.DisablePulumiVersionLog {
 		pt.logPulumiVersionInfo(t)
 	}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/pulumi/providertest/providers"
 	"github.com/pulumi/providertest/pulumitest"
+	"github.com/pulumi/providertest/pulumitest/optnewstack"
 	"github.com/pulumi/providertest/pulumitest/opttest"
 	"github.com/stretchr/testify/assert"
 )
@@ -68,3 +69,19 @@ func TestCleanupDestroyFailureWritesDestroyScript(t *testing.T) {
 	_, err := os.Stat(destroyScriptPath)
 	assert.NoError(t, err, "expected cleanup failure to leave a destroy script behind")
 }
+
+func TestVerifyDestroyDetectsRetainedResources(t *testing.T) {
+	t.Parallel()
+
+	var tt *mockT
+	t.Run("retained resource", func(t *testing.T) {
+		tt = &mockT{T: t}
+		test := pulumitest.NewPulumiTest(tt, filepath.Join("testdata", "yaml_retain_on_delete"),
+			opttest.NewStackOptions(optnewstack.VerifyDestroy()))
+		test.Up(tt)
+		// Deploy again to check resources created by earlier operations are still detected.
+		test.Up(tt)
+	})
+
+	assert.True(t, tt.Failed(), "expected the retained resource to be reported as leaked")
+}
@@ -0,0 +1,51 @@
+package optidempotent
+
+// SkipPreview skips the preview after the initial `pulumi up`.
+func SkipPreview() Option {
+	return optionFunc(func(o *Options) {
+		o.SkipPreview = true
+	})
+}
+
+// SkipReUp skips the second `pulumi up`.
+func SkipReUp() Option {
+	return optionFunc(func(o *Options) {
+		o.SkipReUp = true
+	})
+}
+
+// SkipRefresh skips the final `pulumi refresh`.
+func SkipRefresh() Option {
+	return optionFunc(func(o *Options) {
+		o.SkipRefresh = true
+	})
+}
+
+// IgnoreResourceTypes tolerates changes to resources of the given types, such as resources known to always report a
+// diff. This can be passed multiple times to add more types.
+func IgnoreResourceTypes(resourceTypes ...string) Option {
+	return optionFunc(func(o *Options) {
+		o.IgnoredResourceTypes = append(o.IgnoredResourceTypes, resourceTypes...)
+	})
+}
+
+type Options struct {
+	SkipPreview          bool
+	SkipReUp             bool
+	SkipRefresh          bool
+	IgnoredResourceTypes []string
+}
+
+type Option interface {
+	Apply(*Options)
+}
+
+func Defaults() Options {
+	return Options{}
+}
+
+type optionFunc func(*Options)
+
+func (o optionFunc) Apply(opts *Options) {
+	o(opts)
+}
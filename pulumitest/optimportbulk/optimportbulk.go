@@ -0,0 +1,50 @@
+package optimportbulk
+
+import "github.com/pulumi/providertest/pulumitest/opttest"
+
+// Language generates code for the imported resources in the given language, such as "yaml", "typescript", "python",
+// "go", "csharp" or "java". Defaults to the language of the project.
+func Language(language string) Option {
+	return optionFunc(func(o *Options) {
+		o.Language = language
+	})
+}
+
+// NameTable maps names used in the import file's parent and provider fields to the URNs of existing resources.
+func NameTable(nameTable map[string]string) Option {
+	return optionFunc(func(o *Options) {
+		o.NameTable = nameTable
+	})
+}
+
+// ValidateGeneratedCode creates a new PulumiTest from the generated code and runs a preview, failing the test if the
+// generated program doesn't work. This is currently only supported when generating YAML: validation of other
+// languages is skipped with a log message.
+// The options are applied to the new PulumiTest after the options of the original test.
+func ValidateGeneratedCode(opts ...opttest.Option) Option {
+	return optionFunc(func(o *Options) {
+		o.ValidateGeneratedCode = true
+		o.ValidateOpts = opts
+	})
+}
+
+type Options struct {
+	Language              string
+	NameTable             map[string]string
+	ValidateGeneratedCode bool
+	ValidateOpts          []opttest.Option
+}
+
+type Option interface {
+	Apply(*Options)
+}
+
+func Defaults() Options {
+	return Options{}
+}
+
+type optionFunc func(*Options)
+
+func (o optionFunc) Apply(opts *Options) {
+	o(opts)
+}
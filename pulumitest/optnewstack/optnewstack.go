@@ -16,6 +16,16 @@ func EnableAutoDestroy() NewStackOpt {
 	})
 }
 
+// VerifyDestroy checks the stack was fully destroyed after the automatic `pulumi destroy` at the end of the test.
+// The test fails if the exported state contains any resources other than the stack itself, or if the gRPC log shows
+// resources which were created but never deleted, such as resources with `retainOnDelete` set.
+// To detect created resources, the gRPC log from every operation on the stack is retained until the end of the test.
+func VerifyDestroy() NewStackOpt {
+	return optionFunc(func(o *NewStackOptions) {
+		o.VerifyDestroy = true
+	})
+}
+
 // WithOpts adds additional workspace options for the context of the run.
 func WithOpts(opts ...auto.LocalWorkspaceOption) NewStackOpt {
 	return optionFunc(func(o *NewStackOptions) {
@@ -24,8 +34,9 @@ func WithOpts(opts ...auto.LocalWorkspaceOption) NewStackOpt {
 }
 
 type NewStackOptions struct {
-	SkipDestroy bool
-	Opts        []auto.LocalWorkspaceOption
+	SkipDestroy   bool
+	VerifyDestroy bool
+	Opts          []auto.LocalWorkspaceOption
 }
 
 type NewStackOpt interface {
@@ -1,8 +1,13 @@
 package opttest
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
 
 	"github.com/pulumi/providertest/providers"
 	"github.com/pulumi/providertest/pulumitest/optnewstack"
@@ -181,6 +186,87 @@ func WorkspaceOptions(opts ...auto.LocalWorkspaceOption) Option {
 	})
 }
 
+// Retry sets the policy for retrying Up, Refresh and Destroy operations, including the destroy run when the test is
+// cleaned up, when they fail with a transient error matching one of the policy's patterns.
+// The policy can be overridden for a single operation using pulumitest.UpRetry, RefreshRetry or DestroyRetry.
+// Invalid patterns are reported as a test error when the operation runs.
+func Retry(policy RetryPolicy) Option {
+	return optionFunc(func(o *Options) {
+		o.RetryPolicy = policy
+	})
+}
+
+// RetryPolicy configures retrying operations which fail with transient errors such as throttling or eventual
+// consistency errors.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times to run the operation, including the first attempt.
+	// Operations are not retried if this is less than 2.
+	MaxAttempts int
+	// Patterns are regular expressions matched against the error message, which includes the CLI output.
+	// Only errors matching at least one pattern are retried.
+	Patterns []string
+	// Backoff is the delay before the first retry. The delay doubles after each failed attempt.
+	Backoff time.Duration
+	// MaxBackoff limits the delay between attempts. If zero, the delay is not limited.
+	MaxBackoff time.Duration
+}
+
+// compiledRetryPatterns caches the compiled regular expression for each retry pattern.
+var compiledRetryPatterns sync.Map
+
+func compileRetryPattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := compiledRetryPatterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	compiledRetryPatterns.Store(pattern, re)
+	return re, nil
+}
+
+// Validate returns an error listing each of the policy's patterns which isn't a valid regular expression.
+func (p RetryPolicy) Validate() error {
+	var errs []error
+	for _, pattern := range p.Patterns {
+		if _, err := compileRetryPattern(pattern); err != nil {
+			errs = append(errs, fmt.Errorf("invalid retry pattern %q: %w", pattern, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ShouldRetry returns true if the error matches any of the policy's patterns.
+// Invalid patterns never match; use Validate to find them.
+func (p RetryPolicy) ShouldRetry(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, pattern := range p.Patterns {
+		re, compileErr := compileRetryPattern(pattern)
+		if compileErr == nil && re.MatchString(err.Error()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Delay returns how long to wait after the given failed attempt, starting at 1, before trying again.
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.MaxBackoff > 0 && delay >= p.MaxBackoff {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return delay
+}
+
 func NewStackOptions(opts ...optnewstack.NewStackOpt) Option {
 	return optionFunc(func(o *Options) {
 		o.NewStackOpts = opts
@@ -206,6 +292,7 @@ type Options struct {
 	ExtraWorkspaceOptions   []auto.LocalWorkspaceOption
 	DisableGrpcLog          bool
 	DisablePulumiVersionLog bool
+	RetryPolicy             RetryPolicy
 	// PulumiHome is the PULUMI_HOME to run pulumi commands against. It is set
 	// per-test by NewPulumiTest to isolate Pulumi's on-disk schema cache between
 	// parallel tests. Empty means use the ambient PULUMI_HOME.
@@ -248,6 +335,7 @@ func Defaults() Option {
 		o.ExtraWorkspaceOptions = []auto.LocalWorkspaceOption{}
 		o.DisableGrpcLog = false
 		o.DisablePulumiVersionLog = false
+		o.RetryPolicy = RetryPolicy{}
 		o.TempDir = os.Getenv("PULUMITEST_TEMP_DIR")
 	})
 }
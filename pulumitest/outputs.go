@@ -0,0 +1,117 @@
+package pulumitest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+)
+
+// Outputs returns the outputs of the current stack, including the plaintext values of secret outputs.
+func (pt *PulumiTest) Outputs(t PT) auto.OutputMap {
+	t.Helper()
+
+	if pt.currentStack == nil {
+		ptFatal(t, "no current stack")
+		return nil
+	}
+	outputs, err := pt.currentStack.Outputs(pt.ctx)
+	if err != nil {
+		ptFatalF(t, "failed to get stack outputs: %s", err)
+	}
+	return outputs
+}
+
+// Output is a stack output value along with whether the value was marked as secret.
+// Use it as a field type when decoding outputs with DecodeOutputs to check if an output is secret.
+type Output[T any] struct {
+	Value  T
+	Secret bool
+}
+
+func (o *Output[T]) decodeOutput(value auto.OutputValue) error {
+	o.Secret = value.Secret
+	return decodeOutputValue(value.Value, &o.Value)
+}
+
+type outputDecoder interface {
+	decodeOutput(value auto.OutputValue) error
+}
+
+// DecodeOutputs reads the outputs of the current stack and decodes them into a struct of type T.
+// See DecodeOutputMap for how fields are matched to outputs.
+// The test fails if any output is missing or can't be decoded into its field.
+func DecodeOutputs[T any](t PT, pt *PulumiTest) T {
+	t.Helper()
+
+	decoded, err := DecodeOutputMap[T](pt.Outputs(t))
+	if err != nil {
+		ptFatalF(t, "failed to decode stack outputs: %s", err)
+	}
+	return decoded
+}
+
+// DecodeOutputMap decodes stack outputs, such as those from auto.UpResult, into a struct of type T.
+// Each exported field is matched to the output named by its `pulumi:"name"` tag, or the field name if not tagged.
+// A tag of `pulumi:"-"` skips the field and `pulumi:"name,optional"` allows the output to be missing.
+// Values are converted to the field's type as they would be by encoding/json. Fields of type Output[V] also record
+// whether the output was secret.
+// All missing outputs and type mismatches are returned together in a single error.
+func DecodeOutputMap[T any](outputs auto.OutputMap) (T, error) {
+	var decoded T
+	target := reflect.ValueOf(&decoded).Elem()
+	if target.Kind() != reflect.Struct {
+		return decoded, fmt.Errorf("outputs can only be decoded into a struct, not %s", target.Type())
+	}
+
+	var errs []error
+	for i := 0; i < target.NumField(); i++ {
+		field := target.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, optional := field.Name, false
+		if tag, ok := field.Tag.Lookup("pulumi"); ok {
+			if tag == "-" {
+				continue
+			}
+			tagName, tagOpts, _ := strings.Cut(tag, ",")
+			if tagName != "" {
+				name = tagName
+			}
+			optional = tagOpts == "optional"
+		}
+
+		value, ok := outputs[name]
+		if !ok {
+			if !optional {
+				errs = append(errs, fmt.Errorf("missing output %q for field %s", name, field.Name))
+			}
+			continue
+		}
+		fieldValue := target.Field(i).Addr().Interface()
+		var err error
+		if decoder, ok := fieldValue.(outputDecoder); ok {
+			err = decoder.decodeOutput(value)
+		} else {
+			err = decodeOutputValue(value.Value, fieldValue)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to decode output %q into field %s (%s): %w",
+				name, field.Name, field.Type, err))
+		}
+	}
+	return decoded, errors.Join(errs...)
+}
+
+// decodeOutputValue converts an output value to the target type via its JSON representation.
+func decodeOutputValue(value any, target any) error {
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(valueJSON, target)
+}
@@ -0,0 +1,74 @@
+package pulumitest_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/providertest/pulumitest"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputs(t *testing.T) {
+	t.Parallel()
+	test := pulumitest.NewPulumiTest(t, filepath.Join("testdata", "yaml_program"))
+	test.Up(t)
+
+	outputs := test.Outputs(t)
+	assert.NotEmpty(t, outputs["name"].Value)
+
+	decoded := pulumitest.DecodeOutputs[struct {
+		Name pulumitest.Output[string] `pulumi:"name"`
+	}](t, test)
+	assert.Equal(t, outputs["name"].Value, decoded.Name.Value)
+	assert.False(t, decoded.Name.Secret)
+}
+
+func TestDecodeOutputMap(t *testing.T) {
+	t.Parallel()
+	outputs := auto.OutputMap{
+		"name":     {Value: "pet"},
+		"Region":   {Value: "us-west-2"},
+		"count":    {Value: float64(3)},
+		"password": {Value: "hunter2", Secret: true},
+		"tags":     {Value: map[string]any{"env": "test"}},
+		"ports":    {Value: []any{float64(80), float64(443)}},
+	}
+
+	type nested struct {
+		Env string `json:"env"`
+	}
+
+	t.Run("decodes fields", func(t *testing.T) {
+		decoded, err := pulumitest.DecodeOutputMap[struct {
+			Region   string
+			Count    int                       `pulumi:"count"`
+			Password pulumitest.Output[string] `pulumi:"password"`
+			Tags     pulumitest.Output[nested] `pulumi:"tags"`
+			Ports    []int                     `pulumi:"ports"`
+			Missing  string                    `pulumi:"missing,optional"`
+			Ignored  string                    `pulumi:"-"`
+		}](outputs)
+		require.NoError(t, err)
+		assert.Equal(t, "us-west-2", decoded.Region, "untagged fields match the field name")
+		assert.Equal(t, 3, decoded.Count)
+		assert.Equal(t, pulumitest.Output[string]{Value: "hunter2", Secret: true}, decoded.Password)
+		assert.Equal(t, pulumitest.Output[nested]{Value: nested{Env: "test"}}, decoded.Tags)
+		assert.Equal(t, []int{80, 443}, decoded.Ports)
+	})
+
+	t.Run("reports all errors", func(t *testing.T) {
+		_, err := pulumitest.DecodeOutputMap[struct {
+			Name    int    `pulumi:"name"`
+			Missing string `pulumi:"missing"`
+		}](outputs)
+		assert.ErrorContains(t, err, `failed to decode output "name" into field Name (int)`)
+		assert.ErrorContains(t, err, `missing output "missing" for field Missing`)
+	})
+
+	t.Run("requires a struct", func(t *testing.T) {
+		_, err := pulumitest.DecodeOutputMap[map[string]string](outputs)
+		assert.ErrorContains(t, err, "outputs can only be decoded into a struct")
+	})
+}
@@ -23,18 +23,52 @@ func (pt *PulumiTest) Preview(t PT, opts ...optpreview.Option) auto.PreviewResul
 func (pt *PulumiTest) PreviewErr(t PT, opts ...optpreview.Option) (auto.PreviewResult, error) {
 	t.Helper()
 
+	return pt.previewStack(t, pt.currentStack, opts...)
+}
+
+// PreviewStack previews an update to the named stack, which must have been created by this test.
+// If an error is expected, use `PreviewStackErr` instead to have the error returned.
+func (pt *PulumiTest) PreviewStack(t PT, stackName string, opts ...optpreview.Option) auto.PreviewResult {
+	t.Helper()
+
+	result, err := pt.PreviewStackErr(t, stackName, opts...)
+	if err != nil {
+		ptFatalF(t, "failed to preview update: %s", err)
+	}
+	return result
+}
+
+// PreviewStackErr previews an update to the named stack and returns any error instead of failing the test.
+func (pt *PulumiTest) PreviewStackErr(t PT, stackName string, opts ...optpreview.Option) (auto.PreviewResult, error) {
+	t.Helper()
+
+	stack, err := pt.stackNamed(stackName)
+	if err != nil {
+		return auto.PreviewResult{}, err
+	}
+	return pt.previewStack(t, stack, opts...)
+}
+
+func (pt *PulumiTest) previewStack(t PT, stack *auto.Stack, opts ...optpreview.Option) (auto.PreviewResult, error) {
+	t.Helper()
+
 	t.Log("previewing update")
-	if pt.currentStack == nil {
+	if stack == nil {
 		return auto.PreviewResult{}, fmt.Errorf("no current stack")
 	}
 	if !pt.options.DisableGrpcLog {
-		pt.ClearGrpcLog(t)
+		pt.clearGrpcLog(t, stack)
 	}
 	var result auto.PreviewResult
-	err := pt.withProviders(t, pt.currentStack, func() error {
+	capture := newEventCapture()
+	opts = append(append([]optpreview.Option{}, opts...), previewEventCapture(capture.ch))
+	started := false
+	err := pt.withProviders(t, stack, func() error {
+		started = true
 		var previewErr error
-		result, previewErr = pt.currentStack.Preview(pt.ctx, opts...)
+		result, previewErr = stack.Preview(pt.ctx, opts...)
 		return previewErr
 	})
+	pt.lastEvents = capture.wait(t, started)
 	return result, err
 }
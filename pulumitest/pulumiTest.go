@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/pulumi/providertest/providers"
+	"github.com/pulumi/providertest/pulumitest/engineevents"
 	"github.com/pulumi/providertest/pulumitest/opttest"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto"
 )
@@ -20,6 +21,14 @@ type PulumiTest struct {
 	workingDir   string
 	options      *opttest.Options
 	currentStack *auto.Stack
+	// stacks are all the stacks created by this test, in the order they were created.
+	stacks []*auto.Stack
+	// backendURL is the temporary backend shared by all stacks created by this test.
+	backendURL string
+	// lastEvents are the engine events captured during the last operation.
+	lastEvents engineevents.Events
+	// grpcLogHistoryStacks are the names of stacks whose gRPC logs are kept when cleared before each operation.
+	grpcLogHistoryStacks map[string]bool
 }
 
 // NewPulumiTest creates a new PulumiTest instance.
@@ -29,7 +38,7 @@ type PulumiTest struct {
 // 3. Create a new stack called "test" with state stored to a local temporary directory and a fixed passphrase for encryption.
 func NewPulumiTest(t PT, source string, opts ...opttest.Option) *PulumiTest {
 	t.Helper()
-	ctx := testContext(t)
+	ctx := TestContext(t)
 	options := opttest.DefaultOptions()
 	for _, opt := range opts {
 		opt.Apply(options)
@@ -50,7 +59,8 @@ func NewPulumiTest(t PT, source string, opts ...opttest.Option) *PulumiTest {
 	return pt
 }
 
-func testContext(t PT) context.Context {
+// TestContext returns a context which is cancelled when the test finishes or reaches its deadline.
+func TestContext(t PT) context.Context {
 	t.Helper()
 	var ctx context.Context
 	var cancel context.CancelFunc
@@ -90,11 +100,40 @@ func (pt *PulumiTest) Context() context.Context {
 	return pt.ctx
 }
 
-// CurrentStack returns the last stack that was created or nil if no stack has been created yet.
+// CurrentStack returns the last stack that was created or selected, or nil if no stack has been created yet.
 func (pt *PulumiTest) CurrentStack() *auto.Stack {
 	return pt.currentStack
 }
 
+// Stacks returns all the stacks created by this test, in the order they were created.
+func (pt *PulumiTest) Stacks() []*auto.Stack {
+	return append([]*auto.Stack(nil), pt.stacks...)
+}
+
+// SelectStack sets the current stack to a stack previously created by this test.
+// Operations such as Up and Preview then apply to the selected stack.
+func (pt *PulumiTest) SelectStack(t PT, stackName string) *auto.Stack {
+	t.Helper()
+
+	stack, err := pt.stackNamed(stackName)
+	if err != nil {
+		ptFatal(t, err)
+		return nil
+	}
+	ptLogF(t, "selecting stack %s", stackName)
+	pt.currentStack = stack
+	return stack
+}
+
+func (pt *PulumiTest) stackNamed(stackName string) (*auto.Stack, error) {
+	for _, stack := range pt.stacks {
+		if stack.Name() == stackName {
+			return stack, nil
+		}
+	}
+	return nil, fmt.Errorf("no stack named %q has been created", stackName)
+}
+
 // withProviders starts fresh provider instances for the duration of fn, then stops them.
 // Each engine operation (Preview/Up/Refresh/Destroy) should be wrapped with this so that
 // providers get a clean lifecycle per operation, matching real subprocess-provider behavior.
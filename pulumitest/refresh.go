@@ -23,18 +23,61 @@ func (pt *PulumiTest) Refresh(t PT, opts ...optrefresh.Option) auto.RefreshResul
 func (pt *PulumiTest) RefreshErr(t PT, opts ...optrefresh.Option) (auto.RefreshResult, error) {
 	t.Helper()
 
+	return pt.refreshStack(t, pt.currentStack, opts...)
+}
+
+// RefreshStack refreshes the named stack, which must have been created by this test.
+// If an error is expected, use `RefreshStackErr` instead to have the error returned.
+func (pt *PulumiTest) RefreshStack(t PT, stackName string, opts ...optrefresh.Option) auto.RefreshResult {
+	t.Helper()
+
+	result, err := pt.RefreshStackErr(t, stackName, opts...)
+	if err != nil {
+		ptFatalF(t, "failed to refresh: %s", err)
+	}
+	return result
+}
+
+// RefreshStackErr refreshes the named stack and returns any error instead of failing the test.
+func (pt *PulumiTest) RefreshStackErr(t PT, stackName string, opts ...optrefresh.Option) (auto.RefreshResult, error) {
+	t.Helper()
+
+	stack, err := pt.stackNamed(stackName)
+	if err != nil {
+		return auto.RefreshResult{}, err
+	}
+	return pt.refreshStack(t, stack, opts...)
+}
+
+func (pt *PulumiTest) refreshStack(t PT, stack *auto.Stack, opts ...optrefresh.Option) (auto.RefreshResult, error) {
+	t.Helper()
+
 	t.Log("refreshing")
-	if pt.currentStack == nil {
+	if stack == nil {
 		return auto.RefreshResult{}, fmt.Errorf("no current stack")
 	}
-	if !pt.options.DisableGrpcLog {
-		pt.ClearGrpcLog(t)
+	policy := retryPolicy(pt.options.RetryPolicy, opts)
+	var refreshOptions optrefresh.Options
+	for _, opt := range opts {
+		opt.ApplyOption(&refreshOptions)
 	}
-	var result auto.RefreshResult
-	err := pt.withProviders(t, pt.currentStack, func() error {
-		var refreshErr error
-		result, refreshErr = pt.currentStack.Refresh(pt.ctx, opts...)
-		return refreshErr
+	forwarder := newEventForwarder(refreshOptions.EventStreams)
+	defer forwarder.close()
+	return withRetry(t, pt.ctx, "refresh", policy, func() (auto.RefreshResult, error) {
+		if !pt.options.DisableGrpcLog {
+			pt.clearGrpcLog(t, stack)
+		}
+		var result auto.RefreshResult
+		capture := forwarder.capture()
+		attemptOpts := append(append([]optrefresh.Option{}, opts...), refreshEventCapture(capture.ch))
+		started := false
+		err := pt.withProviders(t, stack, func() error {
+			started = true
+			var refreshErr error
+			result, refreshErr = stack.Refresh(pt.ctx, attemptOpts...)
+			return refreshErr
+		})
+		pt.lastEvents = capture.wait(t, started)
+		return result, err
 	})
-	return result, err
 }
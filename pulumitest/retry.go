@@ -0,0 +1,114 @@
+package pulumitest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pulumi/providertest/pulumitest/opttest"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optdestroy"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optrefresh"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optup"
+)
+
+// UpRetry overrides the test's retry policy for a single Up operation.
+func UpRetry(policy opttest.RetryPolicy) optup.Option {
+	return upRetryOption(policy)
+}
+
+// RefreshRetry overrides the test's retry policy for a single Refresh operation.
+func RefreshRetry(policy opttest.RetryPolicy) optrefresh.Option {
+	return refreshRetryOption(policy)
+}
+
+// DestroyRetry overrides the test's retry policy for a single Destroy operation.
+func DestroyRetry(policy opttest.RetryPolicy) optdestroy.Option {
+	return destroyRetryOption(policy)
+}
+
+// The retry options are only read by pulumitest so don't modify the automation API options.
+type upRetryOption opttest.RetryPolicy
+
+func (upRetryOption) ApplyOption(*optup.Options) {}
+
+type refreshRetryOption opttest.RetryPolicy
+
+func (refreshRetryOption) ApplyOption(*optrefresh.Options) {}
+
+type destroyRetryOption opttest.RetryPolicy
+
+func (destroyRetryOption) ApplyOption(*optdestroy.Options) {}
+
+// retryPolicy returns the last retry policy set in the operation options, or the default policy if none is set.
+func retryPolicy[O any](defaultPolicy opttest.RetryPolicy, opts []O) opttest.RetryPolicy {
+	policy := defaultPolicy
+	for _, opt := range opts {
+		switch o := any(opt).(type) {
+		case upRetryOption:
+			policy = opttest.RetryPolicy(o)
+		case refreshRetryOption:
+			policy = opttest.RetryPolicy(o)
+		case destroyRetryOption:
+			policy = opttest.RetryPolicy(o)
+		}
+	}
+	return policy
+}
+
+// withRetry runs the operation, running it again while it fails with an error matching the retry policy.
+// Each failed attempt is logged. If the operation was retried but still failed, the error lists every attempt.
+func withRetry[T any](t PT, ctx context.Context, operation string, policy opttest.RetryPolicy, run func() (T, error)) (T, error) {
+	t.Helper()
+
+	if err := policy.Validate(); err != nil {
+		ptErrorF(t, "%s retry policy: %v", operation, err)
+	}
+	var attemptErrs []error
+	for attempt := 1; ; attempt++ {
+		result, err := run()
+		if err == nil {
+			if attempt > 1 {
+				ptLogF(t, "%s succeeded on attempt %d of %d", operation, attempt, policy.MaxAttempts)
+			}
+			return result, nil
+		}
+		attemptErrs = append(attemptErrs, err)
+		if attempt >= policy.MaxAttempts || errors.Is(err, errStartProviders) || !policy.ShouldRetry(err) {
+			if attempt == 1 {
+				return result, err
+			}
+			return result, &retryError{operation: operation, attempts: attemptErrs}
+		}
+
+		delay := policy.Delay(attempt)
+		ptLogF(t, "%s attempt %d of %d failed, retrying in %s: %v", operation, attempt, policy.MaxAttempts, delay, err)
+		select {
+		case <-ctx.Done():
+			attemptErrs = append(attemptErrs, ctx.Err())
+			return result, &retryError{operation: operation, attempts: attemptErrs}
+		case <-time.After(delay):
+		}
+	}
+}
+
+// retryError is returned when an operation has been retried but still failed.
+type retryError struct {
+	operation string
+	attempts  []error
+}
+
+func (e *retryError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s failed after %d attempts:", e.operation, len(e.attempts))
+	for i, err := range e.attempts {
+		fmt.Fprintf(&sb, "\nattempt %d: %v", i+1, err)
+	}
+	return sb.String()
+}
+
+// Unwrap returns the error from the last attempt.
+func (e *retryError) Unwrap() error {
+	return e.attempts[len(e.attempts)-1]
+}
@@ -0,0 +1,182 @@
+package pulumitest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pulumi/providertest/pulumitest/opttest"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optdestroy"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optup"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRetry(t *testing.T) {
+	t.Parallel()
+	policy := opttest.RetryPolicy{
+		MaxAttempts: 3,
+		Patterns:    []string{"ThrottlingException", "eventual consistency"},
+		Backoff:     time.Millisecond,
+	}
+
+	t.Run("retries matching errors", func(t *testing.T) {
+		t.Parallel()
+		attempts := 0
+		result, err := withRetry(t, context.Background(), "deploy", policy, func() (int, error) {
+			attempts++
+			if attempts < 3 {
+				return 0, errors.New("ThrottlingException: rate exceeded")
+			}
+			return attempts, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, result)
+	})
+
+	t.Run("lists all attempts", func(t *testing.T) {
+		t.Parallel()
+		lastErr := errors.New("failed due to eventual consistency")
+		_, err := withRetry(t, context.Background(), "deploy", policy, func() (int, error) {
+			return 0, lastErr
+		})
+		assert.EqualError(t, err, "deploy failed after 3 attempts:\n"+
+			"attempt 1: failed due to eventual consistency\n"+
+			"attempt 2: failed due to eventual consistency\n"+
+			"attempt 3: failed due to eventual consistency")
+		assert.ErrorIs(t, err, lastErr)
+	})
+
+	t.Run("does not retry other errors", func(t *testing.T) {
+		t.Parallel()
+		attempts := 0
+		otherErr := errors.New("invalid configuration")
+		_, err := withRetry(t, context.Background(), "deploy", policy, func() (int, error) {
+			attempts++
+			return 0, otherErr
+		})
+		assert.Equal(t, otherErr, err)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("forwards events to caller streams across attempts", func(t *testing.T) {
+		t.Parallel()
+		callerEvents := make(chan events.EngineEvent)
+		received := make(chan int)
+		go func() {
+			count := 0
+			for range callerEvents {
+				count++
+			}
+			received <- count
+		}()
+		opts := []optup.Option{optup.EventStreams(callerEvents)}
+		var upOptions optup.Options
+		for _, opt := range opts {
+			opt.ApplyOption(&upOptions)
+		}
+		forwarder := newEventForwarder(upOptions.EventStreams)
+
+		attempts := 0
+		_, err := withRetry(t, context.Background(), "deploy", policy, func() (int, error) {
+			attempts++
+			capture := forwarder.capture()
+			attemptOpts := append(append([]optup.Option{}, opts...), upEventCapture(capture.ch))
+			// Like the automation API, send the events to every stream then close them.
+			var attemptOptions optup.Options
+			for _, opt := range attemptOpts {
+				opt.ApplyOption(&attemptOptions)
+			}
+			for _, stream := range attemptOptions.EventStreams {
+				stream <- events.EngineEvent{}
+				stream <- events.EngineEvent{}
+				close(stream)
+			}
+			assert.Len(t, capture.wait(t, true), 2)
+			if attempts < 2 {
+				return 0, errors.New("ThrottlingException: rate exceeded")
+			}
+			return attempts, nil
+		})
+		forwarder.close()
+
+		assert.NoError(t, err)
+		assert.Equal(t, 4, <-received)
+	})
+
+	t.Run("reports invalid patterns", func(t *testing.T) {
+		t.Parallel()
+		tt := &failRecorder{T: t}
+		invalid := opttest.RetryPolicy{MaxAttempts: 3, Patterns: []string{`Throttl(ing`}}
+		_, err := withRetry(tt, context.Background(), "deploy", invalid, func() (int, error) {
+			return 0, errors.New("Throttling")
+		})
+		assert.Error(t, err)
+		assert.True(t, tt.failed)
+	})
+
+	t.Run("stops when context is cancelled", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		attempts := 0
+		slowPolicy := policy
+		slowPolicy.Backoff = time.Hour
+		_, err := withRetry(t, ctx, "destroy", slowPolicy, func() (int, error) {
+			attempts++
+			return 0, errors.New("ThrottlingException")
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, attempts)
+	})
+}
+
+func TestRetryPolicy(t *testing.T) {
+	t.Parallel()
+	policy := opttest.RetryPolicy{
+		MaxAttempts: 5,
+		Patterns:    []string{`status code: 5\d\d`},
+		Backoff:     time.Second,
+		MaxBackoff:  5 * time.Second,
+	}
+
+	assert.True(t, policy.ShouldRetry(errors.New("status code: 503")))
+	assert.False(t, policy.ShouldRetry(errors.New("status code: 404")))
+	assert.False(t, policy.ShouldRetry(nil))
+
+	assert.NoError(t, policy.Validate())
+	invalid := opttest.RetryPolicy{Patterns: []string{`Throttl(ing`, `status code: 5\d\d`}}
+	assert.ErrorContains(t, invalid.Validate(), "invalid retry pattern \"Throttl(ing\"")
+	assert.True(t, invalid.ShouldRetry(errors.New("status code: 503")))
+
+	assert.Equal(t, time.Second, policy.Delay(1))
+	assert.Equal(t, 2*time.Second, policy.Delay(2))
+	assert.Equal(t, 4*time.Second, policy.Delay(3))
+	assert.Equal(t, 5*time.Second, policy.Delay(4))
+	assert.Equal(t, 5*time.Second, policy.Delay(10))
+}
+
+func TestRetryPolicyOverride(t *testing.T) {
+	t.Parallel()
+	defaultPolicy := opttest.RetryPolicy{MaxAttempts: 3}
+	override := opttest.RetryPolicy{MaxAttempts: 5}
+
+	assert.Equal(t, defaultPolicy, retryPolicy(defaultPolicy, []optup.Option{optup.Message("test")}))
+	assert.Equal(t, override, retryPolicy(defaultPolicy, []optup.Option{UpRetry(override)}))
+	assert.Equal(t, opttest.RetryPolicy{}, retryPolicy(defaultPolicy, []optdestroy.Option{DestroyRetry(opttest.RetryPolicy{})}))
+}
+
+// failRecorder records failures instead of failing the test.
+type failRecorder struct {
+	*testing.T
+	failed bool
+}
+
+func (r *failRecorder) Fail() {
+	r.failed = true
+}
+
+func (r *failRecorder) FailNow() {
+	r.failed = true
+}
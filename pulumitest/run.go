@@ -5,9 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 
+	"github.com/pulumi/providertest/cachefile"
 	"github.com/pulumi/providertest/pulumitest/optrun"
 	"github.com/pulumi/providertest/pulumitest/sanitize"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
@@ -15,6 +15,7 @@ import (
 
 // Run will run the `execute` function in an isolated temp directory and with additional test options, then import the resulting stack state into the original test.
 // WithCache can be used to skip executing the run and return the cached stack state if available, or to cache the stack state after executing the run.
+// Cache paths ending in ".gz" are gzip-compressed. An existing cache is also found with or without the ".gz" extension.
 // Options will be inherited from the original test, but can be added to with `optrun.WithOpts` or reset with `opttest.Defaults()`.
 func (pulumiTest *PulumiTest) Run(t PT, execute func(test *PulumiTest), opts ...optrun.Option) *PulumiTest {
 	t.Helper()
@@ -26,15 +27,17 @@ func (pulumiTest *PulumiTest) Run(t PT, execute func(test *PulumiTest), opts ...
 
 	var stackExport *apitype.UntypedDeployment
 	var err error
+	cachePath := options.CachePath
 	if options.EnableCache {
-		stackExport, err = tryReadStackExport(options.CachePath)
+		cachePath = cachefile.Resolve(options.CachePath)
+		stackExport, err = tryReadStackExport(cachePath)
 		if err != nil {
 			ptFatalF(t, "failed to read stack export: %v", err)
 		}
 		if stackExport != nil {
-			ptLogF(t, "run cache found at %s", options.CachePath)
+			ptLogF(t, "run cache found at %s", cachePath)
 		} else {
-			ptLogF(t, "no run cache found at %s", options.CachePath)
+			ptLogF(t, "no run cache found at %s", cachePath)
 		}
 	}
 
@@ -49,10 +52,10 @@ func (pulumiTest *PulumiTest) Run(t PT, execute func(test *PulumiTest), opts ...
 				ptError(t, "failed to sanitize secrets from stack state: %v", err)
 			}
 
-			ptLogF(t, "writing stack state to %s", options.CachePath)
-			err = writeStackExport(options.CachePath, sanitizedStack, false /* overwrite */)
+			ptLogF(t, "writing stack state to %s", cachePath)
+			err = writeStackExport(cachePath, sanitizedStack, false /* overwrite */)
 			if err != nil {
-				ptFatalF(t, "failed to write snapshot to %s: %v", options.CachePath, err)
+				ptFatalF(t, "failed to write snapshot to %s: %v", cachePath, err)
 			}
 		}
 		stackExport = &exportedStack
@@ -66,9 +69,9 @@ func (pulumiTest *PulumiTest) Run(t PT, execute func(test *PulumiTest), opts ...
 	}
 	if fixedStack != stackExport {
 		ptLogF(t, "updating snapshot with fixed stack name: %s", stackName)
-		err = writeStackExport(options.CachePath, fixedStack, true /* overwrite */)
+		err = writeStackExport(cachePath, fixedStack, true /* overwrite */)
 		if err != nil {
-			ptFatalF(t, "failed to write snapshot to %s: %v", options.CachePath, err)
+			ptFatalF(t, "failed to write snapshot to %s: %v", cachePath, err)
 		}
 		stackExport = fixedStack
 	}
@@ -77,15 +80,11 @@ func (pulumiTest *PulumiTest) Run(t PT, execute func(test *PulumiTest), opts ...
 }
 
 // writeStackExport writes the stack export to the given path creating any directories needed.
+// If the path ends with ".gz", the stack export is gzip-compressed.
 func writeStackExport(path string, snapshot *apitype.UntypedDeployment, overwrite bool) error {
 	if snapshot == nil {
 		return fmt.Errorf("stack export must not be nil")
 	}
-	dir := filepath.Dir(path)
-	err := os.MkdirAll(dir, 0755)
-	if err != nil {
-		return err
-	}
 	stackBytes, err := json.MarshalIndent(snapshot, "", "  ")
 	if err != nil {
 		return err
@@ -97,13 +96,13 @@ func writeStackExport(path string, snapshot *apitype.UntypedDeployment, overwrit
 	if pathExists && !overwrite {
 		return fmt.Errorf("stack export already exists at %s", path)
 	}
-	return os.WriteFile(path, stackBytes, 0644)
+	return cachefile.WriteFile(path, stackBytes)
 }
 
-// tryReadStackExport reads a stack export from the given file path.
+// tryReadStackExport reads a stack export from the given file path, decompressing it if it's gzip-compressed.
 // If the file does not exist, returns nil, nil.
 func tryReadStackExport(path string) (*apitype.UntypedDeployment, error) {
-	stackBytes, err := os.ReadFile(path)
+	stackBytes, err := cachefile.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, nil
@@ -59,6 +59,29 @@ func TestRunInIsolation(t *testing.T) {
 		assert.Equal(t, preview1.ChangeSummary, preview2.ChangeSummary, "expected uncached and cached preview to be the same")
 	})
 
+	t.Run("compressed cached state", func(t *testing.T) {
+		t.Parallel()
+		cacheDir := t.TempDir()
+		cacheCalls := 0
+
+		test1 := pulumitest.NewPulumiTest(t, filepath.Join("testdata", "yaml_program"))
+		test1.Run(t, func(test *pulumitest.PulumiTest) {
+			test.Up(t)
+			cacheCalls++
+		}, optrun.WithCache(cacheDir, "stack.json.gz"))
+		assert.FileExists(t, filepath.Join(cacheDir, "stack.json.gz"))
+
+		// The compressed cache is used when the uncompressed path is requested.
+		test2 := pulumitest.NewPulumiTest(t, filepath.Join("testdata", "yaml_program"))
+		test2.Run(t, func(test *pulumitest.PulumiTest) {
+			test.Up(t)
+			cacheCalls++
+		}, optrun.WithCache(cacheDir, "stack.json"))
+		assertpreview.HasNoChanges(t, test2.Preview(t))
+
+		assert.Equal(t, 1, cacheCalls, "expected cached method to be called exactly once")
+	})
+
 	t.Run("fix cached stack name", func(t *testing.T) {
 		t.Parallel()
 		cacheDir := t.TempDir()
@@ -0,0 +1,53 @@
+package pulumitest_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/providertest/pulumitest"
+	"github.com/pulumi/providertest/pulumitest/assertpreview"
+	"github.com/pulumi/providertest/pulumitest/opttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultipleStacks(t *testing.T) {
+	t.Parallel()
+	test := pulumitest.NewPulumiTest(t, filepath.Join("testdata", "yaml_program"), opttest.StackName("first"))
+	test.NewStack(t, "second")
+	assert.Equal(t, "second", test.CurrentStack().Name())
+
+	stackNames := []string{}
+	for _, stack := range test.Stacks() {
+		stackNames = append(stackNames, stack.Name())
+	}
+	assert.Equal(t, []string{"first", "second"}, stackNames)
+
+	test.UpStack(t, "first")
+	assertpreview.HasNoChanges(t, test.PreviewStack(t, "first"))
+	// The second stack has not been deployed yet.
+	assert.Equal(t, 2, test.Preview(t).ChangeSummary["create"])
+
+	// All stacks share the same backend.
+	summaries, err := test.CurrentStack().Workspace().ListStacks(test.Context())
+	require.NoError(t, err)
+	assert.Len(t, summaries, 2)
+
+	test.SelectStack(t, "first")
+	assert.Equal(t, "first", test.CurrentStack().Name())
+	test.Refresh(t)
+	test.DestroyStack(t, "first")
+}
+
+func TestSelectUnknownStack(t *testing.T) {
+	t.Parallel()
+	test := pulumitest.NewPulumiTest(t, filepath.Join("testdata", "yaml_program"),
+		opttest.SkipInstall(), opttest.SkipStackCreate())
+
+	tt := mockT{T: t}
+	test.SelectStack(&tt, "missing")
+	assert.True(t, tt.Failed(), "expected selecting an unknown stack to fail")
+
+	_, err := test.UpStackErr(t, "missing")
+	assert.ErrorContains(t, err, `no stack named "missing"`)
+}
@@ -0,0 +1,208 @@
+package pulumitest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/providertest/pulumitest/engineevents"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+)
+
+// StepOperation is the Pulumi operation run by a Step.
+type StepOperation string
+
+const (
+	StepUp      StepOperation = "up"
+	StepPreview StepOperation = "preview"
+	StepRefresh StepOperation = "refresh"
+)
+
+// Step is a single stage of a program's evolution, run by Steps.Run.
+// Before the operation runs, the source overlay is copied over the program, then the Pulumi.yaml is replaced and the
+// config changes are applied.
+type Step struct {
+	// Name identifies the step in failure messages.
+	Name string
+	// Source is a directory of files to copy over the program, as with UpdateSource.
+	Source string
+	// PulumiYAML replaces the contents of the program's Pulumi.yaml, as with WritePulumiYaml.
+	PulumiYAML string
+	// Config values to set on the stack.
+	Config map[string]string
+	// RemoveConfig lists config keys to remove from the stack.
+	RemoveConfig []string
+	// Operation to run. Defaults to StepUp.
+	Operation StepOperation
+	// ExpectChanges maps resources, by name or URN, to the operation expected for them.
+	// Resources not listed must have no changes, other than the stack resource itself and providers. Use an empty map
+	// to expect no changes at all. If nil, the changes are not checked.
+	ExpectChanges map[string]apitype.OpType
+	// ExpectFailure expects the operation to return an error.
+	ExpectFailure bool
+}
+
+// Steps are run in order against the current stack to test the evolution of a program, such as creating resources,
+// then editing a property, adding a resource and removing a resource.
+type Steps []Step
+
+// Run executes each step in order on the current stack of the PulumiTest.
+// Failures are reported with the name of the step. If a step's operation fails unexpectedly, the remaining steps are
+// not run.
+func (s Steps) Run(t PT, pt *PulumiTest) {
+	t.Helper()
+
+	for i, step := range s {
+		name := step.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		ptLogF(t, "running step %s", name)
+		if !pt.runStep(t, name, step) {
+			return
+		}
+	}
+}
+
+// runStep runs a single step, returning false if the remaining steps should not be run.
+func (pt *PulumiTest) runStep(t PT, name string, step Step) bool {
+	t.Helper()
+
+	if pt.currentStack == nil {
+		ptFatalF(t, "step %s: no current stack", name)
+		return false
+	}
+	if step.Source != "" {
+		if err := copyDirectory(step.Source, pt.workingDir); err != nil {
+			ptFatalF(t, "step %s: failed to update source from %s: %s", name, step.Source, err)
+			return false
+		}
+	}
+	if step.PulumiYAML != "" {
+		pt.WritePulumiYaml(t, step.PulumiYAML)
+	}
+	configKeys := make([]string, 0, len(step.Config))
+	for key := range step.Config {
+		configKeys = append(configKeys, key)
+	}
+	sort.Strings(configKeys)
+	for _, key := range configKeys {
+		pt.SetConfig(t, key, step.Config[key])
+	}
+	for _, key := range step.RemoveConfig {
+		if err := pt.currentStack.RemoveConfig(pt.ctx, key); err != nil {
+			ptFatalF(t, "step %s: failed to remove config %q: %s", name, key, err)
+			return false
+		}
+	}
+
+	var err error
+	switch step.Operation {
+	case StepUp, "":
+		_, err = pt.UpErr(t)
+	case StepPreview:
+		_, err = pt.PreviewErr(t)
+	case StepRefresh:
+		_, err = pt.RefreshErr(t)
+	default:
+		ptFatalF(t, "step %s: unknown operation %q", name, step.Operation)
+		return false
+	}
+	if step.ExpectFailure {
+		if err == nil {
+			ptErrorF(t, "step %s: expected %s to fail", name, stepOperationName(step.Operation))
+		}
+	} else if err != nil {
+		ptFatalF(t, "step %s: %s failed: %s", name, stepOperationName(step.Operation), err)
+		return false
+	}
+
+	if step.ExpectChanges != nil {
+		events := pt.Events()
+		if events == nil {
+			ptErrorF(t, "step %s: engine events were not captured for the %s, so its changes could not be checked",
+				name, stepOperationName(step.Operation))
+		} else if problems := checkStepChanges(events, step.ExpectChanges); len(problems) > 0 {
+			ptErrorF(t, "step %s: unexpected changes:\n%s", name, strings.Join(problems, "\n"))
+		}
+	}
+	return true
+}
+
+func stepOperationName(operation StepOperation) string {
+	if operation == "" {
+		return string(StepUp)
+	}
+	return string(operation)
+}
+
+// checkStepChanges compares the resource changes in the events with the expected operation for each resource,
+// returning a description of each mismatch.
+func checkStepChanges(events engineevents.Events, expected map[string]apitype.OpType) []string {
+	changed := map[string][]apitype.StepEventMetadata{}
+	var changedURNs []string
+	for _, step := range events.ChangedSteps() {
+		if _, ok := changed[step.URN]; !ok {
+			changedURNs = append(changedURNs, step.URN)
+		}
+		changed[step.URN] = append(changed[step.URN], step)
+	}
+
+	keys := make([]string, 0, len(expected))
+	for key := range expected {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var problems []string
+	matchedURNs := map[string]bool{}
+	for _, key := range keys {
+		expectedOp := expected[key]
+		var ops []string
+		found := false
+		for _, urn := range changedURNs {
+			if !stepKeyMatchesURN(key, urn) {
+				continue
+			}
+			matchedURNs[urn] = true
+			for _, step := range changed[urn] {
+				ops = append(ops, string(step.Op))
+				if step.Op == expectedOp {
+					found = true
+				}
+			}
+		}
+		switch {
+		case expectedOp == apitype.OpSame && len(ops) > 0:
+			problems = append(problems, fmt.Sprintf("%s: expected same, got %s", key, strings.Join(ops, ", ")))
+		case expectedOp != apitype.OpSame && !found:
+			actual := "same"
+			if len(ops) > 0 {
+				actual = strings.Join(ops, ", ")
+			}
+			problems = append(problems, fmt.Sprintf("%s: expected %s, got %s", key, expectedOp, actual))
+		}
+	}
+
+	var unexpected []apitype.StepEventMetadata
+	for _, urn := range changedURNs {
+		if matchedURNs[urn] {
+			continue
+		}
+		for _, step := range changed[urn] {
+			if step.Type != "pulumi:pulumi:Stack" && !strings.HasPrefix(step.Type, "pulumi:providers:") {
+				unexpected = append(unexpected, step)
+			}
+		}
+	}
+	if len(unexpected) > 0 {
+		problems = append(problems, "changes to resources which weren't expected to change:\n"+
+			strings.TrimRight(engineevents.FormatSteps(unexpected), "\n"))
+	}
+	return problems
+}
+
+// stepKeyMatchesURN returns true if the key is the URN itself or the name of the resource it identifies.
+func stepKeyMatchesURN(key, urn string) bool {
+	return key == urn || urnName(urn) == key
+}
@@ -0,0 +1,101 @@
+package pulumitest
+
+import (
+	"testing"
+
+	"github.com/pulumi/providertest/pulumitest/engineevents"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSteps(t *testing.T) {
+	t.Parallel()
+	test := NewPulumiTest(t, "testdata/yaml_program")
+
+	Steps{
+		{
+			Name:          "create",
+			ExpectChanges: map[string]apitype.OpType{"username": apitype.OpCreate},
+		},
+		{
+			Name:          "add password",
+			Source:        "testdata/yaml_program_updated",
+			ExpectChanges: map[string]apitype.OpType{"password": apitype.OpCreate},
+		},
+		{
+			Name: "edit password length",
+			PulumiYAML: `name: yaml_program
+runtime: yaml
+outputs:
+  name: ${username.id}
+resources:
+  username:
+    type: random:RandomPet
+  password:
+    type: random:RandomPassword
+    properties:
+      length: 12
+`,
+			Operation:     StepPreview,
+			ExpectChanges: map[string]apitype.OpType{"password": apitype.OpReplace},
+		},
+		{
+			Name:          "refresh",
+			Operation:     StepRefresh,
+			ExpectChanges: map[string]apitype.OpType{},
+		},
+	}.Run(t, test)
+}
+
+func TestCheckStepChanges(t *testing.T) {
+	t.Parallel()
+	const (
+		stackURN    = "urn:pulumi:test::project::pulumi:pulumi:Stack::project-test"
+		providerURN = "urn:pulumi:test::project::pulumi:providers:random::default_4_15_0"
+		petURN      = "urn:pulumi:test::project::random:index/randomPet:RandomPet::pet"
+		passwordURN = "urn:pulumi:test::project::random:index/randomPassword:RandomPassword::password"
+	)
+	step := func(op apitype.OpType, urn, resourceType string) events.EngineEvent {
+		return events.EngineEvent{EngineEvent: apitype.EngineEvent{ResourcePreEvent: &apitype.ResourcePreEvent{
+			Metadata: apitype.StepEventMetadata{Op: op, URN: urn, Type: resourceType},
+		}}}
+	}
+	e := engineevents.Events{
+		step(apitype.OpCreate, stackURN, "pulumi:pulumi:Stack"),
+		step(apitype.OpUpdate, petURN, "random:index/randomPet:RandomPet"),
+		step(apitype.OpCreateReplacement, passwordURN, "random:index/randomPassword:RandomPassword"),
+		step(apitype.OpReplace, passwordURN, "random:index/randomPassword:RandomPassword"),
+	}
+
+	t.Run("matches names and URNs", func(t *testing.T) {
+		t.Parallel()
+		assert.Empty(t, checkStepChanges(e, map[string]apitype.OpType{
+			"pet":       apitype.OpUpdate,
+			passwordURN: apitype.OpReplace,
+		}))
+	})
+
+	t.Run("reports mismatches", func(t *testing.T) {
+		t.Parallel()
+		problems := checkStepChanges(e, map[string]apitype.OpType{
+			"pet":     apitype.OpSame,
+			"missing": apitype.OpCreate,
+		})
+		assert.Equal(t, []string{
+			"missing: expected create, got same",
+			"pet: expected same, got update",
+			"changes to resources which weren't expected to change:\n" +
+				"create-replacement: " + passwordURN + "\nreplace: " + passwordURN,
+		}, problems)
+	})
+
+	t.Run("empty expects no changes", func(t *testing.T) {
+		t.Parallel()
+		assert.Len(t, checkStepChanges(e, map[string]apitype.OpType{}), 1)
+		assert.Empty(t, checkStepChanges(engineevents.Events{
+			step(apitype.OpCreate, stackURN, "pulumi:pulumi:Stack"),
+			step(apitype.OpCreate, providerURN, "pulumi:providers:random"),
+		}, map[string]apitype.OpType{}))
+	})
+}
@@ -0,0 +1,278 @@
+package pulumitest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pulumi/providertest/providers"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optremove"
+)
+
+// SweepOptions configures SweepLeakedStacks.
+type SweepOptions struct {
+	// ManifestDir is the directory to read leak manifests from. Defaults to LeakManifestDir().
+	ManifestDir string
+	// MinAge is how long ago a stack must have been leaked before it's swept. This avoids destroying stacks of tests
+	// which are still running or being debugged.
+	MinAge time.Duration
+	// TempDir is the directory the tests created their temp directories in, which is searched for retained temp
+	// directories without a leak manifest. Defaults to PULUMITEST_TEMP_DIR or the OS temporary directory.
+	TempDir string
+	// Providers are started and attached when destroying stacks whose tests attached providers.
+	// Stacks which require a provider not given here are skipped.
+	Providers map[providers.ProviderName]providers.ProviderFactory
+}
+
+// SweepStatus is the outcome of sweeping a single leaked stack.
+type SweepStatus string
+
+const (
+	// SweepDestroyed means the stack was destroyed and removed.
+	SweepDestroyed SweepStatus = "destroyed"
+	// SweepSkipped means the stack couldn't be destroyed with the given options and was left for a later sweep.
+	SweepSkipped SweepStatus = "skipped"
+	// SweepFailed means destroying the stack failed, or its local backend is missing, and it was left for a later sweep.
+	SweepFailed SweepStatus = "failed"
+	// SweepTempDirRemoved means a retained temp directory which no leak manifest refers to was removed.
+	SweepTempDirRemoved SweepStatus = "removed temp dir"
+)
+
+// SweptStack is the result of sweeping a single leak manifest, or of removing a retained temp directory which has no
+// manifest, in which case only Manifest.TempDir is set.
+type SweptStack struct {
+	ManifestPath string
+	Manifest     LeakManifest
+	Status       SweepStatus
+	// Err is the reason the stack was skipped or failed.
+	Err error
+}
+
+// SweepLeakedStacks destroys the stacks recorded in leak manifests older than options.MinAge.
+// Once a stack is destroyed, its manifest is removed along with the test's temporary directory, unless the directory
+// is still referenced by a manifest which wasn't swept.
+// Temp directories which were retained for a leaked stack more than options.MinAge ago but aren't referenced by any
+// remaining manifest, such as when writing the manifest failed, are also removed.
+// An error is only returned if the manifest directory can't be read; the outcome for each manifest is reported in
+// the returned results.
+func SweepLeakedStacks(ctx context.Context, options SweepOptions) ([]SweptStack, error) {
+	dir := options.ManifestDir
+	if dir == "" {
+		dir = LeakManifestDir()
+	}
+	manifestPaths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(manifestPaths)
+
+	var results []SweptStack
+	// Temp dirs still referenced by a manifest which hasn't been swept.
+	retainedTempDirs := map[string]bool{}
+	cutoff := time.Now().Add(-options.MinAge)
+	for _, manifestPath := range manifestPaths {
+		manifest, err := readLeakManifest(manifestPath)
+		if err != nil {
+			results = append(results, SweptStack{
+				ManifestPath: manifestPath,
+				Status:       SweepSkipped,
+				Err:          fmt.Errorf("failed to read leak manifest: %w", err),
+			})
+			continue
+		}
+		if manifest.CreatedAt.After(cutoff) {
+			retainedTempDirs[filepath.Clean(manifest.TempDir)] = true
+			continue
+		}
+		result := sweepLeakedStack(ctx, manifest, options.Providers)
+		result.ManifestPath = manifestPath
+		if result.Status == SweepDestroyed {
+			if err := os.Remove(manifestPath); err != nil && !os.IsNotExist(err) {
+				result.Err = fmt.Errorf("failed to remove leak manifest: %w", err)
+			}
+		} else {
+			retainedTempDirs[filepath.Clean(manifest.TempDir)] = true
+		}
+		results = append(results, result)
+	}
+
+	for i := range results {
+		result := &results[i]
+		if result.Status != SweepDestroyed {
+			continue
+		}
+		tempDir := result.Manifest.TempDir
+		if tempDir == "" || retainedTempDirs[filepath.Clean(tempDir)] {
+			continue
+		}
+		if err := os.RemoveAll(tempDir); err != nil {
+			result.Err = errors.Join(result.Err, fmt.Errorf("failed to remove temp directory %q: %w", tempDir, err))
+		}
+	}
+
+	tempDirRoot := options.TempDir
+	if tempDirRoot == "" {
+		tempDirRoot = defaultTempDirRoot()
+	}
+	results = append(results, sweepRetainedTempDirs(tempDirRoot, cutoff, retainedTempDirs)...)
+	return results, nil
+}
+
+// defaultTempDirRoot returns the directory tests create their temp directories in when not set by an option.
+func defaultTempDirRoot() string {
+	if dir := os.Getenv("PULUMITEST_TEMP_DIR"); dir != "" {
+		if absDir, err := filepath.Abs(dir); err == nil {
+			return absDir
+		}
+		return dir
+	}
+	return os.TempDir()
+}
+
+// sweepRetainedTempDirs removes the temp directories in root which were retained before the cutoff and which aren't
+// referenced by a remaining leak manifest.
+func sweepRetainedTempDirs(root string, cutoff time.Time, referenced map[string]bool) []SweptStack {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return []SweptStack{{
+			Manifest: LeakManifest{TempDir: root},
+			Status:   SweepFailed,
+			Err:      fmt.Errorf("failed to read temp directory: %w", err),
+		}}
+	}
+
+	var results []SweptStack
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		tempDir := filepath.Join(root, entry.Name())
+		if referenced[tempDir] {
+			continue
+		}
+		marker, err := os.Stat(filepath.Join(tempDir, retainedTempDirMarker))
+		if err != nil || marker.ModTime().After(cutoff) {
+			continue
+		}
+		result := SweptStack{Manifest: LeakManifest{TempDir: tempDir}, Status: SweepTempDirRemoved}
+		if err := os.RemoveAll(tempDir); err != nil {
+			result.Status = SweepFailed
+			result.Err = fmt.Errorf("failed to remove temp directory %q: %w", tempDir, err)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func readLeakManifest(path string) (LeakManifest, error) {
+	var manifest LeakManifest
+	manifestBytes, err := os.ReadFile(path)
+	if err != nil {
+		return manifest, err
+	}
+	err = json.Unmarshal(manifestBytes, &manifest)
+	return manifest, err
+}
+
+func sweepLeakedStack(ctx context.Context, manifest LeakManifest, factories map[providers.ProviderName]providers.ProviderFactory) SweptStack {
+	result := SweptStack{Manifest: manifest}
+
+	if backendPath, ok := strings.CutPrefix(manifest.BackendURL, "file://"); ok {
+		if _, err := os.Stat(backendPath); os.IsNotExist(err) {
+			// The resources may still exist, so the manifest is kept for them to be cleaned up manually.
+			result.Status = SweepFailed
+			result.Err = fmt.Errorf("backend %q is missing so the stack can't be destroyed", backendPath)
+			return result
+		}
+	}
+
+	required := map[providers.ProviderName]providers.ProviderFactory{}
+	var missing []string
+	for _, name := range manifest.AttachProviders {
+		factory, ok := factories[providers.ProviderName(name)]
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		required[providers.ProviderName(name)] = factory
+	}
+	if len(missing) > 0 {
+		result.Status = SweepSkipped
+		result.Err = fmt.Errorf("no factory given for attached providers: %s", strings.Join(missing, ", "))
+		return result
+	}
+
+	if err := destroyLeakedStack(ctx, manifest, required); err != nil {
+		result.Status = SweepFailed
+		result.Err = err
+		return result
+	}
+	result.Status = SweepDestroyed
+	return result
+}
+
+func destroyLeakedStack(ctx context.Context, manifest LeakManifest, factories map[providers.ProviderName]providers.ProviderFactory) error {
+	workDir := manifest.WorkingDir
+	if _, err := os.Stat(workDir); err != nil {
+		// The program has been removed, but destroy only needs the project definition.
+		if manifest.ProjectName == "" {
+			return fmt.Errorf("working directory %q is missing and no project name was recorded", workDir)
+		}
+		workDir, err = os.MkdirTemp("", "pulumitest-sweep-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(workDir)
+		project := fmt.Sprintf("name: %s\nruntime: yaml\n", manifest.ProjectName)
+		if err := os.WriteFile(filepath.Join(workDir, "Pulumi.yaml"), []byte(project), 0644); err != nil {
+			return err
+		}
+	}
+
+	env := map[string]string{}
+	if manifest.BackendURL != "" {
+		env["PULUMI_BACKEND_URL"] = manifest.BackendURL
+	}
+	if manifest.ConfigPassphrase != "" {
+		env["PULUMI_CONFIG_PASSPHRASE"] = manifest.ConfigPassphrase
+	}
+	stack, err := auto.SelectStackLocalSource(ctx, manifest.StackName, workDir, auto.EnvVars(env))
+	if err != nil {
+		return fmt.Errorf("failed to select stack %q: %w", manifest.StackName, err)
+	}
+
+	if len(factories) > 0 {
+		providerCtx, cancelProviders := context.WithCancel(ctx)
+		defer cancelProviders()
+		ports, err := providers.StartProviders(providerCtx, factories, sweepSource(workDir))
+		if err != nil {
+			return fmt.Errorf("failed to start providers: %w", err)
+		}
+		stack.Workspace().SetEnvVar("PULUMI_DEBUG_PROVIDERS", providers.GetDebugProvidersEnv(ports))
+	}
+
+	if _, err := stack.Destroy(ctx); err != nil {
+		return fmt.Errorf("failed to destroy stack %q: %w", manifest.StackName, err)
+	}
+	if err := stack.Workspace().RemoveStack(ctx, manifest.StackName, optremove.Force()); err != nil {
+		return fmt.Errorf("failed to remove stack %q: %w", manifest.StackName, err)
+	}
+	return nil
+}
+
+// sweepSource provides the program directory to provider factories when sweeping.
+type sweepSource string
+
+func (s sweepSource) Source() string {
+	return string(s)
+}
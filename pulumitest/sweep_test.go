@@ -0,0 +1,150 @@
+package pulumitest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeakManifestRoundTrip(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	manifest := LeakManifest{
+		StackName:       "test",
+		ProjectName:     "project",
+		BackendURL:      "file:///tmp/backend",
+		WorkingDir:      "/tmp/program",
+		AttachProviders: []string{"random"},
+		TestName:        "TestLeak",
+		Reason:          "cleanup destroy failed",
+		CreatedAt:       time.Now().UTC().Truncate(time.Second),
+	}
+
+	path, err := writeLeakManifestFile(dir, manifest)
+	require.NoError(t, err)
+	assert.Equal(t, dir, filepath.Dir(path))
+
+	read, err := readLeakManifest(path)
+	require.NoError(t, err)
+	assert.Equal(t, manifest, read)
+}
+
+func TestSweepLeakedStacks(t *testing.T) {
+	t.Parallel()
+	manifestDir := t.TempDir()
+	old := time.Now().Add(-2 * time.Hour)
+
+	writeManifest := func(manifest LeakManifest) (string, string) {
+		tempDir := filepath.Join(t.TempDir(), manifest.StackName)
+		require.NoError(t, os.Mkdir(tempDir, 0755))
+		manifest.TempDir = tempDir
+		path, err := writeLeakManifestFile(manifestDir, manifest)
+		require.NoError(t, err)
+		return path, tempDir
+	}
+
+	// The backend directory was removed with the test's temp directory.
+	missingPath, missingTempDir := writeManifest(LeakManifest{
+		StackName:  "missing",
+		BackendURL: "file://" + filepath.Join(t.TempDir(), "removed"),
+		CreatedAt:  old,
+	})
+	needsProviderPath, needsProviderTempDir := writeManifest(LeakManifest{
+		StackName:       "needs-provider",
+		BackendURL:      "file://" + t.TempDir(),
+		AttachProviders: []string{"random"},
+		CreatedAt:       old,
+	})
+	recentPath, recentTempDir := writeManifest(LeakManifest{
+		StackName:  "recent",
+		BackendURL: "file://" + filepath.Join(t.TempDir(), "removed"),
+		CreatedAt:  time.Now(),
+	})
+
+	results, err := SweepLeakedStacks(context.Background(), SweepOptions{
+		ManifestDir: manifestDir,
+		MinAge:      time.Hour,
+		TempDir:     t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	statuses := map[string]SweepStatus{}
+	for _, result := range results {
+		statuses[result.Manifest.StackName] = result.Status
+		switch result.Status {
+		case SweepSkipped:
+			assert.ErrorContains(t, result.Err, "no factory given for attached providers: random")
+		case SweepFailed:
+			assert.ErrorContains(t, result.Err, "is missing so the stack can't be destroyed")
+		}
+	}
+	assert.Equal(t, map[string]SweepStatus{
+		"missing":        SweepFailed,
+		"needs-provider": SweepSkipped,
+	}, statuses, "recent leaks should not be swept")
+
+	assert.FileExists(t, missingPath)
+	assert.DirExists(t, missingTempDir)
+	assert.FileExists(t, needsProviderPath)
+	assert.DirExists(t, needsProviderTempDir)
+	assert.FileExists(t, recentPath)
+	assert.DirExists(t, recentTempDir)
+}
+
+func TestSweepLeakedStacksMissingDir(t *testing.T) {
+	t.Parallel()
+	results, err := SweepLeakedStacks(context.Background(), SweepOptions{
+		ManifestDir: filepath.Join(t.TempDir(), "missing"),
+		TempDir:     filepath.Join(t.TempDir(), "missing"),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestSweepRetainedTempDirs(t *testing.T) {
+	t.Parallel()
+	tempDirRoot := t.TempDir()
+	manifestDir := t.TempDir()
+	old := time.Now().Add(-2 * time.Hour)
+
+	retainedTempDir := func(name string, retainedAt time.Time) string {
+		tempDir := filepath.Join(tempDirRoot, name)
+		require.NoError(t, os.Mkdir(tempDir, 0755))
+		marker := filepath.Join(tempDir, retainedTempDirMarker)
+		require.NoError(t, os.WriteFile(marker, nil, 0644))
+		require.NoError(t, os.Chtimes(marker, retainedAt, retainedAt))
+		return tempDir
+	}
+	orphanedTempDir := retainedTempDir("orphaned", old)
+	recentTempDir := retainedTempDir("recent", time.Now())
+	referencedTempDir := retainedTempDir("referenced", old)
+	unmarkedTempDir := filepath.Join(tempDirRoot, "unmarked")
+	require.NoError(t, os.Mkdir(unmarkedTempDir, 0755))
+	_, err := writeLeakManifestFile(manifestDir, LeakManifest{
+		StackName: "recent",
+		TempDir:   referencedTempDir,
+		CreatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	results, err := SweepLeakedStacks(context.Background(), SweepOptions{
+		ManifestDir: manifestDir,
+		MinAge:      time.Hour,
+		TempDir:     tempDirRoot,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []SweptStack{{
+		Manifest: LeakManifest{TempDir: orphanedTempDir},
+		Status:   SweepTempDirRemoved,
+	}}, results)
+	assert.NoDirExists(t, orphanedTempDir)
+	assert.DirExists(t, recentTempDir)
+	assert.DirExists(t, referencedTempDir)
+	assert.DirExists(t, unmarkedTempDir)
+}
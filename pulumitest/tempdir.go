@@ -78,6 +78,10 @@ func tempDirWithoutCleanupOnFailedTest(t PT, desc, tempDir string) string {
 					t.Log("To remove these directories on failures, set PULUMITEST_RETAIN_FILES_ON_FAILURE=false")
 					return
 				}
+				if c.isRetained() {
+					ptLogF(t, "Skipping removal of %s temp directories as they're needed to destroy a leaked stack: %q", desc, c.tempDir)
+					return
+				}
 				if shouldAlwaysRetainFiles() {
 					ptLogF(t, "Skipping removal of %s temp directories as `PULUMITEST_RETAIN_FILES` is enabled: %q", desc, c.tempDir)
 					return
@@ -113,6 +117,26 @@ type tempDirState struct {
 	tempDirMu  sync.Mutex
 	tempDirSeq int
 	tempDirErr error
+	// retained is set when the temp directory is still needed after the test, such as for a leaked stack's backend.
+	retained bool
+}
+
+// retainedTempDirMarker is written to a retained temp directory so that SweepLeakedStacks can find and remove it later,
+// even if its leak manifest is missing.
+const retainedTempDirMarker = ".pulumitest-retained"
+
+// retain prevents the temp directory being removed when the test is cleaned up.
+func (c *tempDirState) retain() error {
+	c.tempDirMu.Lock()
+	defer c.tempDirMu.Unlock()
+	c.retained = true
+	return os.WriteFile(filepath.Join(c.tempDir, retainedTempDirMarker), nil, 0644)
+}
+
+func (c *tempDirState) isRetained() bool {
+	c.tempDirMu.Lock()
+	defer c.tempDirMu.Unlock()
+	return c.retained
 }
 
 var tempDirStates sync.Map
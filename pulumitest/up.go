@@ -23,18 +23,61 @@ func (pt *PulumiTest) Up(t PT, opts ...optup.Option) auto.UpResult {
 func (pt *PulumiTest) UpErr(t PT, opts ...optup.Option) (auto.UpResult, error) {
 	t.Helper()
 
+	return pt.upStack(t, pt.currentStack, opts...)
+}
+
+// UpStack deploys the named stack, which must have been created by this test.
+// If an error is expected, use `UpStackErr` instead to have the error returned.
+func (pt *PulumiTest) UpStack(t PT, stackName string, opts ...optup.Option) auto.UpResult {
+	t.Helper()
+
+	result, err := pt.UpStackErr(t, stackName, opts...)
+	if err != nil {
+		ptFatalF(t, "failed to deploy: %s", err)
+	}
+	return result
+}
+
+// UpStackErr deploys the named stack and returns any error instead of failing the test.
+func (pt *PulumiTest) UpStackErr(t PT, stackName string, opts ...optup.Option) (auto.UpResult, error) {
+	t.Helper()
+
+	stack, err := pt.stackNamed(stackName)
+	if err != nil {
+		return auto.UpResult{}, err
+	}
+	return pt.upStack(t, stack, opts...)
+}
+
+func (pt *PulumiTest) upStack(t PT, stack *auto.Stack, opts ...optup.Option) (auto.UpResult, error) {
+	t.Helper()
+
 	t.Log("deploying")
-	if pt.currentStack == nil {
+	if stack == nil {
 		return auto.UpResult{}, fmt.Errorf("no current stack")
 	}
-	if !pt.options.DisableGrpcLog {
-		pt.ClearGrpcLog(t)
+	policy := retryPolicy(pt.options.RetryPolicy, opts)
+	var upOptions optup.Options
+	for _, opt := range opts {
+		opt.ApplyOption(&upOptions)
 	}
-	var result auto.UpResult
-	err := pt.withProviders(t, pt.currentStack, func() error {
-		var upErr error
-		result, upErr = pt.currentStack.Up(pt.ctx, opts...)
-		return upErr
+	forwarder := newEventForwarder(upOptions.EventStreams)
+	defer forwarder.close()
+	return withRetry(t, pt.ctx, "deploy", policy, func() (auto.UpResult, error) {
+		if !pt.options.DisableGrpcLog {
+			pt.clearGrpcLog(t, stack)
+		}
+		var result auto.UpResult
+		capture := forwarder.capture()
+		attemptOpts := append(append([]optup.Option{}, opts...), upEventCapture(capture.ch))
+		started := false
+		err := pt.withProviders(t, stack, func() error {
+			started = true
+			var upErr error
+			result, upErr = stack.Up(pt.ctx, attemptOpts...)
+			return upErr
+		})
+		pt.lastEvents = capture.wait(t, started)
+		return result, err
 	})
-	return result, err
 }
@@ -0,0 +1,156 @@
+package pulumitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pulumi/providertest/grpclog"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+)
+
+// grpcLogHistorySuffix is appended to the gRPC log path to name the file holding the logs of previous operations.
+const grpcLogHistorySuffix = ".history"
+
+// leakedResource is a resource which was created by a provider but never deleted.
+type leakedResource struct {
+	URN string
+	ID  string
+}
+
+// verifyDestroyed checks that the stack has been destroyed, failing the test if any resources remain in the state or
+// if the gRPC log shows created resources which were never deleted.
+func (pt *PulumiTest) verifyDestroyed(t PT, stack *auto.Stack) {
+	t.Helper()
+
+	ptLogF(t, "verifying stack %s was destroyed", stack.Name())
+	deployment, err := stack.Workspace().ExportStack(pt.ctx, stack.Name())
+	if err != nil {
+		ptErrorF(t, "failed to export stack %q to verify destroy: %s", stack.Name(), err)
+	} else {
+		remaining, err := remainingResources(deployment.Deployment)
+		if err != nil {
+			ptErrorF(t, "failed to read state of stack %q to verify destroy: %s", stack.Name(), err)
+		} else if len(remaining) > 0 {
+			ptErrorF(t, "expected stack %q to be empty after destroy, found %d resources:\n%s",
+				stack.Name(), len(remaining), strings.Join(remaining, "\n"))
+		}
+	}
+
+	grpcLogPath := stack.Workspace().GetEnvVars()["PULUMI_DEBUG_GRPC"]
+	if grpcLogPath == "" {
+		ptLogF(t, "skipping leaked resource detection for stack %q: gRPC log is disabled", stack.Name())
+		return
+	}
+	log, err := loadGrpcLogHistory(grpcLogPath)
+	if err != nil {
+		ptErrorF(t, "failed to load gRPC log to detect leaked resources: %s", err)
+		return
+	}
+	leaked, err := findLeakedResources(log)
+	if err != nil {
+		ptErrorF(t, "failed to detect leaked resources: %s", err)
+		return
+	}
+	if len(leaked) > 0 {
+		var lines []string
+		for _, resource := range leaked {
+			lines = append(lines, fmt.Sprintf("%s (ID: %s)", resource.URN, resource.ID))
+		}
+		ptErrorF(t, "found %d resources in stack %q which were created but never deleted:\n%s",
+			len(leaked), stack.Name(), strings.Join(lines, "\n"))
+	}
+}
+
+// remainingResources returns a description of each resource in the deployment other than the stack itself.
+func remainingResources(deployment json.RawMessage) ([]string, error) {
+	var state struct {
+		Resources []struct {
+			URN  string `json:"urn"`
+			Type string `json:"type"`
+			ID   string `json:"id"`
+		} `json:"resources"`
+	}
+	if err := json.Unmarshal(deployment, &state); err != nil {
+		return nil, err
+	}
+	var remaining []string
+	for _, resource := range state.Resources {
+		if resource.Type == "pulumi:pulumi:Stack" {
+			continue
+		}
+		if resource.ID != "" {
+			remaining = append(remaining, fmt.Sprintf("%s (ID: %s)", resource.URN, resource.ID))
+		} else {
+			remaining = append(remaining, resource.URN)
+		}
+	}
+	return remaining, nil
+}
+
+// findLeakedResources compares the resources created in the gRPC log with the resources deleted, returning each
+// created resource which was never deleted.
+func findLeakedResources(log *grpclog.GrpcLog) ([]leakedResource, error) {
+	creates, err := log.Creates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read creates from gRPC log: %w", err)
+	}
+	deletes, err := log.Deletes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read deletes from gRPC log: %w", err)
+	}
+	deleted := map[leakedResource]bool{}
+	// Avoid using range due to entries containing sync locks.
+	for i := range deletes {
+		deleted[leakedResource{URN: deletes[i].Request.GetUrn(), ID: deletes[i].Request.GetId()}] = true
+	}
+	var leaked []leakedResource
+	for i := range creates {
+		create := &creates[i]
+		if create.Request.GetPreview() || create.Response.GetId() == "" {
+			continue
+		}
+		resource := leakedResource{URN: create.Request.GetUrn(), ID: create.Response.GetId()}
+		if !deleted[resource] {
+			leaked = append(leaked, resource)
+		}
+	}
+	return leaked, nil
+}
+
+// retainGrpcLogHistory appends the current gRPC log to the history file so that it's kept when the log is cleared
+// before the next operation.
+func retainGrpcLogHistory(grpcLogPath string) error {
+	current, err := os.ReadFile(grpcLogPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	history, err := os.OpenFile(grpcLogPath+grpcLogHistorySuffix, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = history.Write(append(current, '\n'))
+	closeErr := history.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// loadGrpcLogHistory loads the gRPC logs of all previous operations along with the current gRPC log.
+func loadGrpcLogHistory(grpcLogPath string) (*grpclog.GrpcLog, error) {
+	var combined []byte
+	for _, path := range []string{grpcLogPath + grpcLogHistorySuffix, grpcLogPath} {
+		contents, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		combined = append(combined, contents...)
+		combined = append(combined, '\n')
+	}
+	return grpclog.ParseLog(combined)
+}
@@ -0,0 +1,65 @@
+package pulumitest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/providertest/grpclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const bucketURN = "urn:pulumi:p-it-antons-mac-bucket-9f59db4a::test::aws:s3/bucket:Bucket::tested-resource"
+
+func TestFindLeakedResources(t *testing.T) {
+	t.Parallel()
+	log, err := grpclog.LoadLog(filepath.Join("..", "grpclog", "testdata", "aws_bucket_grpc.json"))
+	require.NoError(t, err)
+
+	leaked, err := findLeakedResources(log)
+	require.NoError(t, err)
+	assert.Empty(t, leaked, "expected the bucket to be deleted")
+
+	var withoutDeletes grpclog.GrpcLog
+	for _, entry := range log.Entries {
+		if entry.Method != string(grpclog.Delete) {
+			withoutDeletes.Entries = append(withoutDeletes.Entries, entry)
+		}
+	}
+	leaked, err = findLeakedResources(&withoutDeletes)
+	require.NoError(t, err)
+	assert.Equal(t, []leakedResource{{URN: bucketURN, ID: "testbucket-p-it-antons-mac-bucket-9f59db4a"}}, leaked)
+}
+
+func TestRemainingResources(t *testing.T) {
+	t.Parallel()
+	remaining, err := remainingResources([]byte(`{"resources": [
+		{"urn": "urn:pulumi:test::project::pulumi:pulumi:Stack::project-test", "type": "pulumi:pulumi:Stack"},
+		{"urn": "urn:pulumi:test::project::random:index/randomPet:RandomPet::pet", "type": "random:index/randomPet:RandomPet", "id": "pet-1"}
+	]}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"urn:pulumi:test::project::random:index/randomPet:RandomPet::pet (ID: pet-1)"}, remaining)
+
+	remaining, err = remainingResources([]byte(`{}`))
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+}
+
+func TestGrpcLogHistory(t *testing.T) {
+	t.Parallel()
+	grpcLogPath := filepath.Join(t.TempDir(), "grpc.json")
+	// Retaining a missing log is a no-op.
+	require.NoError(t, retainGrpcLogHistory(grpcLogPath))
+
+	require.NoError(t, os.WriteFile(grpcLogPath, []byte(`{"method":"/pulumirpc.ResourceProvider/Create"}`+"\n"), 0644))
+	require.NoError(t, retainGrpcLogHistory(grpcLogPath))
+	require.NoError(t, os.WriteFile(grpcLogPath, []byte(`{"method":"/pulumirpc.ResourceProvider/Delete"}`), 0644))
+
+	log, err := loadGrpcLogHistory(grpcLogPath)
+	require.NoError(t, err)
+	assert.Equal(t, []grpclog.GrpcLogEntry{
+		{Method: string(grpclog.Create)},
+		{Method: string(grpclog.Delete)},
+	}, log.Entries)
+}
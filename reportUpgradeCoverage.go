@@ -16,65 +16,211 @@ package providertest
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
-	"testing"
 
-	"github.com/stretchr/testify/require"
+	"github.com/blang/semver"
+	"github.com/pulumi/providertest/cachefile"
+	"github.com/pulumi/providertest/grpclog"
+	"github.com/pulumi/providertest/optproviderupgrade"
+	"github.com/pulumi/providertest/pulumitest"
 )
 
 // This is a temporary helper method to assess upgrade resource coverage until better methods for
 // tracking coverage are built. Run with -test.v to see the data logged. This finds all recorded
 // GRPC states and traverses them to find the union of all resources used. It does not take into
 // account if the corresponding tests are skipped or passing.
-func ReportUpgradeCoverage(t *testing.T) {
+// If no cacheDirTemplate is provided, the default cache directory template is used.
+func ReportUpgradeCoverage(t pulumitest.PT, cacheDirTemplate ...string) {
 	t.Helper()
-	u := &upgradeCoverage{}
-	dir := filepath.Join("testdata", "recorded", "TestProviderUpgrade")
+	report := GetUpgradeCoverage(t, cacheDirTemplate...)
 
-	states := findFiles(t, dir, func(fn string) bool {
-		filename := filepath.Base(fn)
-		// Check for both the old name (state) from PulumiTest and the current name (stack).
-		return filename == "stack.json" || filename == "state.json"
+	covered := report.ResourceTypes()
+	t.Log(fmt.Sprintf("Resources covered: %d", len(covered)))
+	for _, s := range covered {
+		t.Log(fmt.Sprintf("- %s", s))
+	}
+}
+
+// UpgradeCoverageReport describes which resource types are covered by recorded upgrade baselines.
+type UpgradeCoverageReport struct {
+	// Programs are the programs with recorded baselines, sorted by name.
+	Programs []ProgramUpgradeCoverage `json:"programs"`
+}
+
+// ProgramUpgradeCoverage describes the recorded baselines for a single program.
+type ProgramUpgradeCoverage struct {
+	ProgramName string `json:"programName"`
+	// Baselines are the recorded baselines for the program, sorted by version.
+	Baselines []BaselineUpgradeCoverage `json:"baselines"`
+}
+
+// BaselineUpgradeCoverage describes the resources recorded in a single baseline.
+type BaselineUpgradeCoverage struct {
+	BaselineVersion string `json:"baselineVersion"`
+	// Resources is the number of resources of each type in the recorded stack state.
+	Resources map[string]int `json:"resources"`
+	// Functions is the number of invokes of each function in the recorded gRPC log.
+	Functions map[string]int `json:"functions,omitempty"`
+}
+
+// GetUpgradeCoverage finds all recorded upgrade baselines matching the cache directory template and returns the
+// resource types and counts recorded for each program and baseline version.
+// If no cacheDirTemplate is provided, the default cache directory template is used.
+func GetUpgradeCoverage(t pulumitest.PT, cacheDirTemplate ...string) *UpgradeCoverageReport {
+	t.Helper()
+	if len(cacheDirTemplate) == 0 {
+		cacheDirTemplate = optproviderupgrade.Defaults().CacheDirTemplate
+	}
+	cacheDirs, err := findUpgradeCacheDirs(cacheDirTemplate)
+	if err != nil {
+		t.Log(fmt.Sprintf("failed to find upgrade cache directories: %v", err))
+		t.FailNow()
+		return nil
+	}
+
+	programs := map[string]*ProgramUpgradeCoverage{}
+	for _, cacheDir := range cacheDirs {
+		u := &upgradeCoverage{}
+		// Check for both the old name (state) from PulumiTest and the current name (stack), using the first which exists.
+		for _, filename := range []string{baselineStackFile, "state.json"} {
+			if u.checkStateFile(t, filepath.Join(cacheDir.path, filename)) {
+				break
+			}
+		}
+		if u.resources == nil {
+			continue
+		}
+		u.checkGrpcLogFile(t, filepath.Join(cacheDir.path, baselineGrpcLogFile))
+		program, ok := programs[cacheDir.programName]
+		if !ok {
+			program = &ProgramUpgradeCoverage{ProgramName: cacheDir.programName}
+			programs[cacheDir.programName] = program
+		}
+		program.Baselines = append(program.Baselines, BaselineUpgradeCoverage{
+			BaselineVersion: cacheDir.baselineVersion,
+			Resources:       u.resources,
+			Functions:       u.functions,
+		})
+	}
+
+	report := &UpgradeCoverageReport{Programs: []ProgramUpgradeCoverage{}}
+	for _, program := range programs {
+		sort.SliceStable(program.Baselines, func(i, j int) bool {
+			return compareVersions(program.Baselines[i].BaselineVersion, program.Baselines[j].BaselineVersion) < 0
+		})
+		report.Programs = append(report.Programs, *program)
+	}
+	sort.SliceStable(report.Programs, func(i, j int) bool {
+		return report.Programs[i].ProgramName < report.Programs[j].ProgramName
 	})
+	return report
+}
 
-	for _, s := range states {
-		u.checkStateFile(t, s)
+// ResourceTypes returns the sorted union of all resource types covered by any baseline.
+func (r *UpgradeCoverageReport) ResourceTypes() []string {
+	return sortedKeys(r.ResourceCounts())
+}
+
+// ResourceCounts returns the total number of resources of each type across all baselines.
+func (r *UpgradeCoverageReport) ResourceCounts() map[string]int {
+	counts := map[string]int{}
+	for _, program := range r.Programs {
+		for _, baseline := range program.Baselines {
+			for resourceType, count := range baseline.Resources {
+				counts[resourceType] += count
+			}
+		}
 	}
+	return counts
+}
 
-	covered := u.resources
-	t.Logf("Resources covered: %d", len(covered))
+// FunctionTypes returns the sorted union of all functions invoked by any baseline.
+func (r *UpgradeCoverageReport) FunctionTypes() []string {
+	return sortedKeys(r.FunctionCounts())
+}
 
-	sorted := []string{}
-	for k := range covered {
-		sorted = append(sorted, k)
+// FunctionCounts returns the total number of invokes of each function across all baselines.
+func (r *UpgradeCoverageReport) FunctionCounts() map[string]int {
+	counts := map[string]int{}
+	for _, program := range r.Programs {
+		for _, baseline := range program.Baselines {
+			for function, count := range baseline.Functions {
+				counts[function] += count
+			}
+		}
 	}
-	sort.Strings(sorted)
-	for _, s := range sorted {
-		t.Logf("- %s", s)
+	return counts
+}
+
+// WriteJSON writes the report as JSON to the given path, creating any directories needed.
+func (r *UpgradeCoverageReport) WriteJSON(path string) error {
+	reportBytes, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
 	}
+	return writeReportFile(path, reportBytes)
 }
 
-// Tracks resource coverage through upgrade tests.
-type upgradeCoverage struct {
-	resources map[string]struct{}
+// WriteMarkdown writes the report as Markdown to the given path, creating any directories needed.
+func (r *UpgradeCoverageReport) WriteMarkdown(path string) error {
+	return writeReportFile(path, []byte(r.Markdown()))
 }
 
-func findFiles(t *testing.T, dir string, matches func(string) bool) []string {
-	var files []string
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if matches(path) {
-			files = append(files, path)
+// Markdown renders the report as a Markdown document with a summary table of all resource types followed by the
+// resources recorded in each baseline. Functions are only included where invokes were recorded.
+func (r *UpgradeCoverageReport) Markdown() string {
+	var sb strings.Builder
+	counts := r.ResourceCounts()
+	functionCounts := r.FunctionCounts()
+	sb.WriteString("# Upgrade Coverage\n\n")
+	fmt.Fprintf(&sb, "Resource types covered: %d\n\n", len(counts))
+	if len(functionCounts) > 0 {
+		fmt.Fprintf(&sb, "Functions covered: %d\n\n", len(functionCounts))
+	}
+	writeMarkdownCountTable(&sb, "Resource Type", counts)
+	writeMarkdownCountTable(&sb, "Function", functionCounts)
+	for _, program := range r.Programs {
+		fmt.Fprintf(&sb, "\n## %s\n", program.ProgramName)
+		for _, baseline := range program.Baselines {
+			fmt.Fprintf(&sb, "\n### %s\n\n", baseline.BaselineVersion)
+			writeMarkdownCountTable(&sb, "Resource Type", baseline.Resources)
+			writeMarkdownCountTable(&sb, "Function", baseline.Functions)
 		}
-		return nil
-	})
-	require.NoError(t, err)
-	return files
+	}
+	return sb.String()
+}
+
+func writeMarkdownCountTable(sb *strings.Builder, heading string, counts map[string]int) {
+	if len(counts) == 0 && heading != "Resource Type" {
+		return
+	}
+	fmt.Fprintf(sb, "| %s | Count |\n| --- | --- |\n", heading)
+	for _, key := range sortedKeys(counts) {
+		fmt.Fprintf(sb, "| `%s` | %d |\n", key, counts[key])
+	}
+}
+
+func writeReportFile(path string, contents []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, contents, 0644)
 }
 
-func (u *upgradeCoverage) checkStateFile(t *testing.T, stateFile string) {
+// Tracks resource coverage through upgrade tests.
+type upgradeCoverage struct {
+	resources map[string]int
+	functions map[string]int
+}
+
+// checkStateFile counts the resources in the state file, returning false if the file couldn't be read.
+func (u *upgradeCoverage) checkStateFile(t pulumitest.PT, stateFile string) bool {
+	t.Helper()
 	type stack struct {
 		Deployment struct {
 			Resources []struct {
@@ -82,25 +228,144 @@ func (u *upgradeCoverage) checkStateFile(t *testing.T, stateFile string) {
 			} `json:"resources"`
 		} `json:"deployment"`
 	}
-	b, err := os.ReadFile(stateFile)
+	b, err := cachefile.ReadFile(stateFile)
 	if err != nil {
-		return // perhaps it did not exist, no matter
+		return false // perhaps it did not exist, no matter
 	}
 
 	var st stack
-	require.NoError(t, json.Unmarshal(b, &st))
+	if err := json.Unmarshal(b, &st); err != nil {
+		t.Log(fmt.Sprintf("failed to unmarshal state file %s: %v", stateFile, err))
+		t.FailNow()
+		return false
+	}
 
 	if u.resources == nil {
-		u.resources = map[string]struct{}{}
+		u.resources = map[string]int{}
 	}
 
 	for _, r := range st.Deployment.Resources {
-		if strings.Contains(r.Type, "providers") {
+		if r.Type == "pulumi:pulumi:Stack" || strings.HasPrefix(r.Type, "pulumi:providers:") {
 			continue
 		}
-		if strings.Contains(r.Type, "Stack") {
+		u.resources[r.Type]++
+	}
+	return true
+}
+
+func (u *upgradeCoverage) checkGrpcLogFile(t pulumitest.PT, grpcLogFile string) {
+	t.Helper()
+	if _, err := os.Stat(cachefile.Resolve(grpcLogFile)); err != nil {
+		return // perhaps it did not exist, no matter
+	}
+	log, err := grpclog.LoadLog(grpcLogFile)
+	if err != nil {
+		t.Log(fmt.Sprintf("failed to load gRPC log %s: %v", grpcLogFile, err))
+		t.FailNow()
+		return
+	}
+	invokes, err := log.Invokes()
+	if err != nil {
+		t.Log(fmt.Sprintf("failed to read invokes from gRPC log %s: %v", grpcLogFile, err))
+		t.FailNow()
+		return
+	}
+	// Avoid using range due to invokes containing sync locks.
+	for i := 0; i < len(invokes); i++ {
+		if u.functions == nil {
+			u.functions = map[string]int{}
+		}
+		u.functions[invokes[i].Request.GetTok()]++
+	}
+}
+
+type upgradeCacheDir struct {
+	path            string
+	programName     string
+	baselineVersion string
+}
+
+// findUpgradeCacheDirs finds all existing directories matching the cache directory template, as used by
+// GetUpgradeCacheDir, and extracts the program name and baseline version from each matching path.
+func findUpgradeCacheDirs(cacheDirTemplate []string) ([]upgradeCacheDir, error) {
+	// Split the template into path components so each placeholder matches exactly one directory level.
+	var components []string
+	for _, elem := range cacheDirTemplate {
+		if elem == optproviderupgrade.ProgramName || elem == optproviderupgrade.BaselineVersion {
+			components = append(components, elem)
 			continue
 		}
-		u.resources[r.Type] = struct{}{}
+		components = append(components, strings.Split(filepath.ToSlash(filepath.Clean(elem)), "/")...)
+	}
+
+	var pattern []string
+	for i, component := range components {
+		switch {
+		case component == optproviderupgrade.ProgramName || component == optproviderupgrade.BaselineVersion:
+			pattern = append(pattern, "*")
+		case component == "" && i == 0:
+			pattern = append(pattern, "") // Absolute path
+		default:
+			pattern = append(pattern, escapeGlob(component))
+		}
+	}
+	matches, err := filepath.Glob(filepath.FromSlash(strings.Join(pattern, "/")))
+	if err != nil {
+		return nil, err
+	}
+
+	var cacheDirs []upgradeCacheDir
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			continue
+		}
+		matchComponents := strings.Split(filepath.ToSlash(match), "/")
+		if len(matchComponents) != len(components) {
+			continue
+		}
+		cacheDir := upgradeCacheDir{path: match}
+		for i, component := range components {
+			switch component {
+			case optproviderupgrade.ProgramName:
+				cacheDir.programName = matchComponents[i]
+			case optproviderupgrade.BaselineVersion:
+				cacheDir.baselineVersion = matchComponents[i]
+			}
+		}
+		cacheDirs = append(cacheDirs, cacheDir)
+	}
+	return cacheDirs, nil
+}
+
+// escapeGlob escapes glob meta-characters so the string is matched literally.
+// Escaping is not supported by filepath.Match on Windows.
+func escapeGlob(s string) string {
+	if runtime.GOOS == "windows" {
+		return s
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`)
+	return replacer.Replace(s)
+}
+
+// compareVersions compares two versions semantically if both can be parsed, otherwise lexically.
+func compareVersions(a, b string) int {
+	aVersion, aErr := semver.ParseTolerant(a)
+	bVersion, bErr := semver.ParseTolerant(b)
+	if aErr == nil && bErr == nil {
+		return aVersion.Compare(bVersion)
+	}
+	return strings.Compare(a, b)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
+	return keys
 }
@@ -0,0 +1,120 @@
+package providertest_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/providertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUpgradeCoverage(t *testing.T) {
+	t.Parallel()
+	cacheDir := t.TempDir()
+	writeState := func(program, version string, types ...string) {
+		type resource struct {
+			Type string `json:"type"`
+		}
+		resources := []resource{{Type: "pulumi:pulumi:Stack"}, {Type: "pulumi:providers:random"}}
+		for _, typ := range types {
+			resources = append(resources, resource{Type: typ})
+		}
+		state := map[string]any{"deployment": map[string]any{"resources": resources}}
+		stateBytes, err := json.Marshal(state)
+		require.NoError(t, err)
+		dir := filepath.Join(cacheDir, "custom", program, version)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "stack.json"), stateBytes, 0644))
+	}
+	writeState("yaml_program", "4.10.0", "random:index/randomString:RandomString", "random:index/randomString:RandomString")
+	writeState("yaml_program", "4.5.0", "random:index/randomPet:RandomPet")
+	writeState("other_program", "4.5.0", "random:index/randomPet:RandomPet")
+	// Directories without a recorded state are ignored.
+	require.NoError(t, os.MkdirAll(filepath.Join(cacheDir, "custom", "empty_program", "4.5.0"), 0755))
+
+	report := providertest.GetUpgradeCoverage(t, cacheDir, "custom", "{programName}", "{baselineVersion}")
+
+	assert.Equal(t, &providertest.UpgradeCoverageReport{
+		Programs: []providertest.ProgramUpgradeCoverage{
+			{
+				ProgramName: "other_program",
+				Baselines: []providertest.BaselineUpgradeCoverage{
+					{BaselineVersion: "4.5.0", Resources: map[string]int{"random:index/randomPet:RandomPet": 1}},
+				},
+			},
+			{
+				ProgramName: "yaml_program",
+				Baselines: []providertest.BaselineUpgradeCoverage{
+					{BaselineVersion: "4.5.0", Resources: map[string]int{"random:index/randomPet:RandomPet": 1}},
+					{BaselineVersion: "4.10.0", Resources: map[string]int{"random:index/randomString:RandomString": 2}},
+				},
+			},
+		},
+	}, report)
+	assert.Equal(t, []string{"random:index/randomPet:RandomPet", "random:index/randomString:RandomString"}, report.ResourceTypes())
+
+	t.Run("json", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "report", "coverage.json")
+		require.NoError(t, report.WriteJSON(path))
+		reportBytes, err := os.ReadFile(path)
+		require.NoError(t, err)
+		var roundTripped providertest.UpgradeCoverageReport
+		require.NoError(t, json.Unmarshal(reportBytes, &roundTripped))
+		assert.Equal(t, report, &roundTripped)
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		assert.Equal(t, "# Upgrade Coverage\n\n"+
+			"Resource types covered: 2\n\n"+
+			"| Resource Type | Count |\n| --- | --- |\n"+
+			"| `random:index/randomPet:RandomPet` | 2 |\n"+
+			"| `random:index/randomString:RandomString` | 2 |\n"+
+			"\n## other_program\n"+
+			"\n### 4.5.0\n\n"+
+			"| Resource Type | Count |\n| --- | --- |\n"+
+			"| `random:index/randomPet:RandomPet` | 1 |\n"+
+			"\n## yaml_program\n"+
+			"\n### 4.5.0\n\n"+
+			"| Resource Type | Count |\n| --- | --- |\n"+
+			"| `random:index/randomPet:RandomPet` | 1 |\n"+
+			"\n### 4.10.0\n\n"+
+			"| Resource Type | Count |\n| --- | --- |\n"+
+			"| `random:index/randomString:RandomString` | 2 |\n",
+			report.Markdown())
+	})
+}
+
+func TestGetUpgradeCoverageResourcesNamedStack(t *testing.T) {
+	t.Parallel()
+	cacheDir := t.TempDir()
+	state := `{"deployment": {"resources": [
+		{"type": "pulumi:pulumi:Stack"},
+		{"type": "pulumi:providers:aws"},
+		{"type": "aws:cloudformation/stack:Stack"},
+		{"type": "aws:cloudformation/stackSet:StackSet"}
+	]}}`
+	dir := filepath.Join(cacheDir, "program", "1.0.0")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stack.json"), []byte(state), 0644))
+
+	report := providertest.GetUpgradeCoverage(t, cacheDir, "{programName}", "{baselineVersion}")
+
+	assert.Equal(t, []string{"aws:cloudformation/stack:Stack", "aws:cloudformation/stackSet:StackSet"}, report.ResourceTypes())
+}
+
+func TestGetUpgradeCoverageStackAndStateFiles(t *testing.T) {
+	t.Parallel()
+	cacheDir := t.TempDir()
+	state := `{"deployment": {"resources": [{"type": "random:index/randomPet:RandomPet"}]}}`
+	dir := filepath.Join(cacheDir, "program", "1.0.0")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stack.json"), []byte(state), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "state.json"), []byte(state), 0644))
+
+	report := providertest.GetUpgradeCoverage(t, cacheDir, "{programName}", "{baselineVersion}")
+
+	assert.Equal(t, map[string]int{"random:index/randomPet:RandomPet": 1}, report.ResourceCounts())
+}
@@ -0,0 +1,361 @@
+package providertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/providertest/providers"
+	"github.com/pulumi/providertest/pulumitest"
+)
+
+// SchemaChangeKind classifies a breaking change between two versions of a provider schema.
+type SchemaChangeKind string
+
+const (
+	// ResourceRemoved is a resource token which no longer exists and has no replacement.
+	ResourceRemoved SchemaChangeKind = "resource-removed"
+	// FunctionRemoved is a function token which no longer exists.
+	FunctionRemoved SchemaChangeKind = "function-removed"
+	// TokenRenamedWithoutAlias is a resource which appears to have been renamed, but the new token has no alias to
+	// the old token so existing resources would be replaced.
+	TokenRenamedWithoutAlias SchemaChangeKind = "token-renamed-without-alias"
+	// PropertyRemoved is an input or output property which no longer exists.
+	PropertyRemoved SchemaChangeKind = "property-removed"
+	// TypeChanged is a property whose type has changed.
+	TypeChanged SchemaChangeKind = "type-changed"
+	// RequiredInputAdded is an input which is now required but was previously optional or didn't exist. For object
+	// types, this is only reported where the type is used as an input.
+	RequiredInputAdded SchemaChangeKind = "required-input-added"
+	// ReplaceOnChangesAdded is an input which now forces the resource to be replaced when changed.
+	ReplaceOnChangesAdded SchemaChangeKind = "replace-on-changes-added"
+)
+
+// SchemaBreakingChange describes a single breaking change to a resource, function or type.
+type SchemaBreakingChange struct {
+	Kind SchemaChangeKind `json:"kind"`
+	// Token is the resource, function or type token.
+	Token string `json:"token"`
+	// Property is the affected property, prefixed with "inputs." or "outputs." for resources and functions.
+	Property string `json:"property,omitempty"`
+	Old      string `json:"old,omitempty"`
+	New      string `json:"new,omitempty"`
+}
+
+func (c SchemaBreakingChange) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: %s", c.Kind, c.Token)
+	if c.Property != "" {
+		sb.WriteString(" " + c.Property)
+	}
+	if c.Old != "" || c.New != "" {
+		fmt.Fprintf(&sb, " (%s => %s)", c.Old, c.New)
+	}
+	return sb.String()
+}
+
+// SchemaBreakingChanges lists the breaking changes between two schemas, sorted by token, property and kind.
+type SchemaBreakingChanges []SchemaBreakingChange
+
+// OfKind returns only the changes of the given kind.
+func (c SchemaBreakingChanges) OfKind(kind SchemaChangeKind) SchemaBreakingChanges {
+	filtered := SchemaBreakingChanges{}
+	for _, change := range c {
+		if change.Kind == kind {
+			filtered = append(filtered, change)
+		}
+	}
+	return filtered
+}
+
+func (c SchemaBreakingChanges) String() string {
+	if len(c) == 0 {
+		return "no breaking changes"
+	}
+	lines := make([]string, len(c))
+	for i, change := range c {
+		lines[i] = change.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// CheckProviderSchemaUpgrade downloads the baseline version of the provider, fetches its schema through GetSchema and
+// compares it with the schema of the new provider started from the factory.
+// This catches breaking changes to any resource or function, not just those exercised by a program under test.
+func CheckProviderSchemaUpgrade(t pulumitest.PT, providerName, baselineVersion string, factory providers.ProviderFactory) SchemaBreakingChanges {
+	t.Helper()
+	baselineSchema := GetProviderSchema(t, providers.DownloadPluginBinaryFactory(providerName, baselineVersion))
+	newSchema := GetProviderSchema(t, factory)
+	changes, err := CompareProviderSchemas(baselineSchema, newSchema)
+	if err != nil {
+		t.Log(err.Error())
+		t.FailNow()
+		return nil
+	}
+	return changes
+}
+
+// AssertNoSchemaBreakingChanges fails the test if there are any breaking changes, logging each change.
+func AssertNoSchemaBreakingChanges(t pulumitest.PT, changes SchemaBreakingChanges) {
+	t.Helper()
+	if len(changes) > 0 {
+		t.Log(fmt.Sprintf("found %d breaking schema changes:\n%s", len(changes), changes))
+		t.Fail()
+	}
+}
+
+// GetProviderSchema starts the provider from the factory and returns the JSON schema from its GetSchema method.
+// The provider is stopped before returning.
+func GetProviderSchema(t pulumitest.PT, factory providers.ProviderFactory) []byte {
+	t.Helper()
+	schema, err := getProviderSchema(pulumitest.TestContext(t), factory)
+	if err != nil {
+		t.Log(fmt.Sprintf("failed to get provider schema: %v", err))
+		t.FailNow()
+		return nil
+	}
+	return schema
+}
+
+// The subset of the Pulumi package schema needed to detect breaking changes.
+type schemaSpec struct {
+	Provider  schemaResourceSpec            `json:"provider"`
+	Resources map[string]schemaResourceSpec `json:"resources"`
+	Functions map[string]schemaFunctionSpec `json:"functions"`
+	Types     map[string]schemaObjectSpec   `json:"types"`
+}
+
+type schemaObjectSpec struct {
+	Properties map[string]schemaPropertySpec `json:"properties"`
+	Required   []string                      `json:"required"`
+}
+
+type schemaResourceSpec struct {
+	schemaObjectSpec
+	InputProperties map[string]schemaPropertySpec `json:"inputProperties"`
+	RequiredInputs  []string                      `json:"requiredInputs"`
+	Aliases         []struct {
+		Type *string `json:"type"`
+	} `json:"aliases"`
+}
+
+type schemaFunctionSpec struct {
+	Inputs  *schemaObjectSpec `json:"inputs"`
+	Outputs *schemaObjectSpec `json:"outputs"`
+}
+
+type schemaPropertySpec struct {
+	Type                 string               `json:"type"`
+	Ref                  string               `json:"$ref"`
+	Items                *schemaPropertySpec  `json:"items"`
+	AdditionalProperties *schemaPropertySpec  `json:"additionalProperties"`
+	OneOf                []schemaPropertySpec `json:"oneOf"`
+	WillReplaceOnChanges bool                 `json:"willReplaceOnChanges"`
+}
+
+// typeString returns a readable description of the property type for comparison.
+func (p schemaPropertySpec) typeString() string {
+	switch {
+	case p.Ref != "":
+		return p.Ref
+	case len(p.OneOf) > 0:
+		types := make([]string, len(p.OneOf))
+		for i, t := range p.OneOf {
+			types[i] = t.typeString()
+		}
+		return strings.Join(types, "|")
+	case p.Type == "array" && p.Items != nil:
+		return "[]" + p.Items.typeString()
+	case p.Type == "object" && p.AdditionalProperties != nil:
+		return "map[string]" + p.AdditionalProperties.typeString()
+	default:
+		return p.Type
+	}
+}
+
+// CompareProviderSchemas finds the breaking changes from the old to the new JSON provider schema.
+func CompareProviderSchemas(oldSchemaBytes, newSchemaBytes []byte) (SchemaBreakingChanges, error) {
+	var oldSchema, newSchema schemaSpec
+	if err := json.Unmarshal(oldSchemaBytes, &oldSchema); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal old provider schema: %w", err)
+	}
+	if err := json.Unmarshal(newSchemaBytes, &newSchema); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal new provider schema: %w", err)
+	}
+
+	changes := SchemaBreakingChanges{}
+	aliased := map[string]bool{}
+	for _, res := range newSchema.Resources {
+		for _, alias := range res.Aliases {
+			if alias.Type != nil {
+				aliased[*alias.Type] = true
+			}
+		}
+	}
+	for _, token := range sortedKeys(oldSchema.Resources) {
+		oldRes := oldSchema.Resources[token]
+		newRes, ok := newSchema.Resources[token]
+		if !ok {
+			if aliased[token] {
+				continue
+			}
+			if renamed := findRenamedToken(token, oldSchema.Resources, newSchema.Resources); renamed != "" {
+				changes = append(changes, SchemaBreakingChange{Kind: TokenRenamedWithoutAlias, Token: token, Old: token, New: renamed})
+			} else {
+				changes = append(changes, SchemaBreakingChange{Kind: ResourceRemoved, Token: token})
+			}
+			continue
+		}
+		changes = append(changes, compareInputs(token, "inputs.", oldRes.InputProperties, oldRes.RequiredInputs,
+			newRes.InputProperties, newRes.RequiredInputs, true)...)
+		changes = append(changes, compareProperties(token, "outputs.", oldRes.Properties, newRes.Properties)...)
+	}
+	for _, token := range sortedKeys(oldSchema.Functions) {
+		oldFn := oldSchema.Functions[token]
+		newFn, ok := newSchema.Functions[token]
+		if !ok {
+			changes = append(changes, SchemaBreakingChange{Kind: FunctionRemoved, Token: token})
+			continue
+		}
+		oldInputs, newInputs := objectOrEmpty(oldFn.Inputs), objectOrEmpty(newFn.Inputs)
+		changes = append(changes, compareInputs(token, "inputs.", oldInputs.Properties, oldInputs.Required,
+			newInputs.Properties, newInputs.Required, false)...)
+		changes = append(changes, compareProperties(token, "outputs.",
+			objectOrEmpty(oldFn.Outputs).Properties, objectOrEmpty(newFn.Outputs).Properties)...)
+	}
+	inputs := inputTypes(newSchema)
+	for _, token := range sortedKeys(oldSchema.Types) {
+		newType, ok := newSchema.Types[token]
+		if !ok {
+			// Removed types only matter where a property referencing them is removed or changes type.
+			continue
+		}
+		oldType := oldSchema.Types[token]
+		// Newly required properties only break programs where the type is used as an input.
+		if inputs[token] {
+			changes = append(changes, compareInputs(token, "", oldType.Properties, oldType.Required,
+				newType.Properties, newType.Required, false)...)
+		} else {
+			changes = append(changes, compareProperties(token, "", oldType.Properties, newType.Properties)...)
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Token != changes[j].Token {
+			return changes[i].Token < changes[j].Token
+		}
+		if changes[i].Property != changes[j].Property {
+			return changes[i].Property < changes[j].Property
+		}
+		return changes[i].Kind < changes[j].Kind
+	})
+	return changes, nil
+}
+
+// inputTypes returns the tokens of the types which are used by the inputs of the provider, resources or functions,
+// either directly or through other types.
+func inputTypes(schema schemaSpec) map[string]bool {
+	reachable := map[string]bool{}
+	var visit func(prop schemaPropertySpec)
+	visitAll := func(props map[string]schemaPropertySpec) {
+		for _, prop := range props {
+			visit(prop)
+		}
+	}
+	visit = func(prop schemaPropertySpec) {
+		if ref, ok := strings.CutPrefix(prop.Ref, "#/types/"); ok {
+			token, err := url.PathUnescape(ref)
+			if err != nil {
+				token = ref
+			}
+			if !reachable[token] {
+				reachable[token] = true
+				visitAll(schema.Types[token].Properties)
+			}
+		}
+		if prop.Items != nil {
+			visit(*prop.Items)
+		}
+		if prop.AdditionalProperties != nil {
+			visit(*prop.AdditionalProperties)
+		}
+		for _, oneOf := range prop.OneOf {
+			visit(oneOf)
+		}
+	}
+	visitAll(schema.Provider.InputProperties)
+	for _, res := range schema.Resources {
+		visitAll(res.InputProperties)
+	}
+	for _, fn := range schema.Functions {
+		visitAll(objectOrEmpty(fn.Inputs).Properties)
+	}
+	return reachable
+}
+
+func objectOrEmpty(spec *schemaObjectSpec) schemaObjectSpec {
+	if spec == nil {
+		return schemaObjectSpec{}
+	}
+	return *spec
+}
+
+func compareInputs(token, prefix string, oldProps map[string]schemaPropertySpec, oldRequired []string,
+	newProps map[string]schemaPropertySpec, newRequired []string, checkReplaceOnChanges bool) []SchemaBreakingChange {
+	changes := compareProperties(token, prefix, oldProps, newProps)
+	wasRequired := map[string]bool{}
+	for _, name := range oldRequired {
+		wasRequired[name] = true
+	}
+	for _, name := range newRequired {
+		if !wasRequired[name] {
+			changes = append(changes, SchemaBreakingChange{Kind: RequiredInputAdded, Token: token, Property: prefix + name})
+		}
+	}
+	if checkReplaceOnChanges {
+		for _, name := range sortedKeys(newProps) {
+			oldProp, ok := oldProps[name]
+			if ok && !oldProp.WillReplaceOnChanges && newProps[name].WillReplaceOnChanges {
+				changes = append(changes, SchemaBreakingChange{Kind: ReplaceOnChangesAdded, Token: token, Property: prefix + name})
+			}
+		}
+	}
+	return changes
+}
+
+func compareProperties(token, prefix string, oldProps, newProps map[string]schemaPropertySpec) []SchemaBreakingChange {
+	var changes []SchemaBreakingChange
+	for _, name := range sortedKeys(oldProps) {
+		newProp, ok := newProps[name]
+		if !ok {
+			changes = append(changes, SchemaBreakingChange{Kind: PropertyRemoved, Token: token, Property: prefix + name})
+			continue
+		}
+		oldType, newType := oldProps[name].typeString(), newProp.typeString()
+		if oldType != newType {
+			changes = append(changes, SchemaBreakingChange{Kind: TypeChanged, Token: token, Property: prefix + name, Old: oldType, New: newType})
+		}
+	}
+	return changes
+}
+
+// findRenamedToken looks for a new resource with the same name as the removed token, but in a different module.
+func findRenamedToken(token string, oldResources, newResources map[string]schemaResourceSpec) string {
+	name := tokenName(token)
+	for _, candidate := range sortedKeys(newResources) {
+		if _, existed := oldResources[candidate]; existed {
+			continue
+		}
+		if strings.EqualFold(tokenName(candidate), name) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// tokenName returns the last segment of a token, such as "Bucket" for "aws:s3/bucket:Bucket".
+func tokenName(token string) string {
+	return token[strings.LastIndex(token, ":")+1:]
+}
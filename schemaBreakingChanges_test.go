@@ -0,0 +1,98 @@
+package providertest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pulumi/providertest"
+	"github.com/pulumi/providertest/providers"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const oldBreakingSchema = `{
+  "resources": {
+    "test:index:Bucket": {
+      "properties": {"arn": {"type": "string"}, "size": {"type": "integer"}},
+      "inputProperties": {
+        "name": {"type": "string"},
+        "tags": {"type": "object", "additionalProperties": {"type": "string"}},
+        "rules": {"type": "array", "items": {"$ref": "#/types/test:index:Rule"}}
+      },
+      "requiredInputs": []
+    },
+    "test:index:Queue": {"properties": {}, "inputProperties": {}},
+    "test:old:Topic": {"properties": {}, "inputProperties": {}},
+    "test:old:Table": {"properties": {}, "inputProperties": {}},
+    "test:index:Removed": {"properties": {}, "inputProperties": {}}
+  },
+  "functions": {
+    "test:index:getBucket": {"inputs": {"properties": {"name": {"type": "string"}}}},
+    "test:index:getRemoved": {}
+  },
+  "types": {
+    "test:index:Rule": {"properties": {"port": {"type": "integer"}, "target": {"$ref": "#/types/test:index%2Fnested:Target"}}},
+    "test:index/nested:Target": {"properties": {"host": {"type": "string"}}},
+    "test:index:Status": {"properties": {"state": {"type": "string"}}}
+  }
+}`
+
+const newBreakingSchema = `{
+  "resources": {
+    "test:index:Bucket": {
+      "properties": {"arn": {"type": "string"}, "size": {"type": "string"}, "status": {"$ref": "#/types/test:index:Status"}},
+      "inputProperties": {
+        "name": {"type": "string", "willReplaceOnChanges": true},
+        "region": {"type": "string"},
+        "rules": {"type": "array", "items": {"$ref": "#/types/test:index:Rule"}}
+      },
+      "requiredInputs": ["region"]
+    },
+    "test:index:Queue": {"properties": {}, "inputProperties": {}},
+    "test:new:Topic": {"properties": {}, "inputProperties": {}},
+    "test:new:Table": {"properties": {}, "inputProperties": {}, "aliases": [{"type": "test:old:Table"}]}
+  },
+  "functions": {
+    "test:index:getBucket": {"inputs": {"properties": {}}}
+  },
+  "types": {
+    "test:index:Rule": {"properties": {"port": {"type": "string"}, "target": {"$ref": "#/types/test:index%2Fnested:Target"}}},
+    "test:index/nested:Target": {"properties": {"host": {"type": "string"}}, "required": ["host"]},
+    "test:index:Status": {"properties": {"state": {"type": "string"}}, "required": ["state"]}
+  }
+}`
+
+func TestCompareProviderSchemas(t *testing.T) {
+	t.Parallel()
+	changes, err := providertest.CompareProviderSchemas([]byte(oldBreakingSchema), []byte(newBreakingSchema))
+	require.NoError(t, err)
+
+	assert.Equal(t, providertest.SchemaBreakingChanges{
+		{Kind: providertest.RequiredInputAdded, Token: "test:index/nested:Target", Property: "host"},
+		{Kind: providertest.ReplaceOnChangesAdded, Token: "test:index:Bucket", Property: "inputs.name"},
+		{Kind: providertest.RequiredInputAdded, Token: "test:index:Bucket", Property: "inputs.region"},
+		{Kind: providertest.PropertyRemoved, Token: "test:index:Bucket", Property: "inputs.tags"},
+		{Kind: providertest.TypeChanged, Token: "test:index:Bucket", Property: "outputs.size", Old: "integer", New: "string"},
+		{Kind: providertest.ResourceRemoved, Token: "test:index:Removed"},
+		{Kind: providertest.TypeChanged, Token: "test:index:Rule", Property: "port", Old: "integer", New: "string"},
+		{Kind: providertest.PropertyRemoved, Token: "test:index:getBucket", Property: "inputs.name"},
+		{Kind: providertest.FunctionRemoved, Token: "test:index:getRemoved"},
+		{Kind: providertest.TokenRenamedWithoutAlias, Token: "test:old:Topic", Old: "test:old:Topic", New: "test:new:Topic"},
+	}, changes, changes.String())
+
+	assert.Len(t, changes.OfKind(providertest.PropertyRemoved), 2)
+	assert.Equal(t, "no breaking changes", providertest.SchemaBreakingChanges{}.String())
+}
+
+func TestGetProviderSchema(t *testing.T) {
+	t.Parallel()
+	factory := providers.ProviderMockFactory(providers.ProviderMocks{
+		GetSchema: func(ctx context.Context, in *pulumirpc.GetSchemaRequest) (*pulumirpc.GetSchemaResponse, error) {
+			return &pulumirpc.GetSchemaResponse{Schema: newBreakingSchema}, nil
+		},
+	})
+
+	schema := providertest.GetProviderSchema(t, factory)
+	assert.JSONEq(t, newBreakingSchema, string(schema))
+}
@@ -0,0 +1,38 @@
+package providertest
+
+import (
+	"github.com/pulumi/providertest/optproviderupgrade"
+	"github.com/pulumi/providertest/pulumitest"
+	"github.com/pulumi/providertest/pulumitest/optnewstack"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+)
+
+// UpProviderUpgrade captures the state of a stack from a baseline provider configuration, then deploys the stack
+// with the current provider configuration.
+// This exercises the new provider's ability to update the state written by the baseline version, rather than only
+// previewing the upgrade. The stack is destroyed at the end of the test.
+// Uses the same cache directory and options as PreviewProviderUpgrade.
+func UpProviderUpgrade(t pulumitest.PT, pulumiTest *pulumitest.PulumiTest, providerName string, baselineVersion string, opts ...optproviderupgrade.PreviewProviderUpgradeOpt) auto.UpResult {
+	t.Helper()
+	options := optproviderupgrade.Defaults()
+	for _, opt := range opts {
+		opt.Apply(&options)
+	}
+	upTest := prepareUpgradeTest(t, pulumiTest, providerName, baselineVersion, options, optnewstack.EnableAutoDestroy())
+	return upTest.Up(t)
+}
+
+// RefreshProviderUpgrade captures the state of a stack from a baseline provider configuration, then refreshes the
+// stack with the current provider configuration.
+// This exercises the new provider's ability to read the state written by the baseline version.
+// The stack is destroyed at the end of the test.
+// Uses the same cache directory and options as PreviewProviderUpgrade.
+func RefreshProviderUpgrade(t pulumitest.PT, pulumiTest *pulumitest.PulumiTest, providerName string, baselineVersion string, opts ...optproviderupgrade.PreviewProviderUpgradeOpt) auto.RefreshResult {
+	t.Helper()
+	options := optproviderupgrade.Defaults()
+	for _, opt := range opts {
+		opt.Apply(&options)
+	}
+	refreshTest := prepareUpgradeTest(t, pulumiTest, providerName, baselineVersion, options, optnewstack.EnableAutoDestroy())
+	return refreshTest.Refresh(t)
+}
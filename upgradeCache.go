@@ -0,0 +1,78 @@
+package providertest
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pulumi/providertest/cachefile"
+	"github.com/pulumi/providertest/grpclog"
+)
+
+const (
+	baselineStackFile   = "stack.json"
+	baselineGrpcLogFile = "grpc.json"
+)
+
+// baselineCachePaths returns the paths of the stack state and gRPC log to write when recording a baseline.
+func baselineCachePaths(cacheDir string, compress bool) (stackPath, grpcLogPath string) {
+	stackPath = filepath.Join(cacheDir, baselineStackFile)
+	grpcLogPath = filepath.Join(cacheDir, baselineGrpcLogFile)
+	if compress {
+		stackPath += cachefile.GzipExtension
+		grpcLogPath += cachefile.GzipExtension
+	}
+	return stackPath, grpcLogPath
+}
+
+// CompactUpgradeCache rewrites all the recorded baselines found under the given directory in place.
+// Entries which are not needed for replaying gRPC logs are removed. If compress is true, stack state and gRPC logs
+// are gzip-compressed and the uncompressed files are removed, otherwise the existing compression is kept.
+func CompactUpgradeCache(dir string, compress bool) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		switch strings.TrimSuffix(d.Name(), cachefile.GzipExtension) {
+		case baselineGrpcLogFile:
+			return compactGrpcLog(path, compress)
+		case baselineStackFile, "state.json":
+			if compress && !cachefile.IsCompressed(path) {
+				return compressCacheFile(path)
+			}
+		}
+		return nil
+	})
+}
+
+func compactGrpcLog(path string, compress bool) error {
+	log, err := grpclog.LoadLog(path)
+	if err != nil {
+		return fmt.Errorf("failed to load gRPC log %s: %w", path, err)
+	}
+	log.Compact()
+	target := path
+	if compress && !cachefile.IsCompressed(path) {
+		target = path + cachefile.GzipExtension
+	}
+	if err := log.WriteTo(target); err != nil {
+		return fmt.Errorf("failed to write gRPC log %s: %w", target, err)
+	}
+	if target != path {
+		return os.Remove(path)
+	}
+	return nil
+}
+
+func compressCacheFile(path string) error {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := cachefile.WriteFile(path+cachefile.GzipExtension, contents); err != nil {
+		return fmt.Errorf("failed to compress %s: %w", path, err)
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,47 @@
+package providertest_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/providertest"
+	"github.com/pulumi/providertest/grpclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompactUpgradeCache(t *testing.T) {
+	t.Parallel()
+	log, err := grpclog.LoadLog(filepath.Join("grpclog", "testdata", "aws_bucket_grpc.json"))
+	require.NoError(t, err)
+	log.Entries = append(log.Entries, grpclog.GrpcLogEntry{
+		Method:   string(grpclog.GetSchema),
+		Response: []byte(`{"schema":"{}"}`),
+	})
+
+	cacheDir := filepath.Join(t.TempDir(), "TestProviderUpgrade", "program", "1.0.0")
+	require.NoError(t, log.WriteTo(filepath.Join(cacheDir, "grpc.json")))
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "stack.json"), []byte(`{"version":3}`), 0644))
+
+	t.Run("compact", func(t *testing.T) {
+		require.NoError(t, providertest.CompactUpgradeCache(cacheDir, false))
+		compacted, err := grpclog.LoadLog(filepath.Join(cacheDir, "grpc.json"))
+		require.NoError(t, err)
+		assert.Empty(t, compacted.WhereMethod(grpclog.GetSchema))
+		assert.Less(t, len(compacted.Entries), len(log.Entries))
+		assert.FileExists(t, filepath.Join(cacheDir, "stack.json"))
+	})
+
+	t.Run("compress", func(t *testing.T) {
+		require.NoError(t, providertest.CompactUpgradeCache(cacheDir, true))
+		assert.NoFileExists(t, filepath.Join(cacheDir, "grpc.json"))
+		assert.NoFileExists(t, filepath.Join(cacheDir, "stack.json"))
+		assert.FileExists(t, filepath.Join(cacheDir, "grpc.json.gz"))
+		assert.FileExists(t, filepath.Join(cacheDir, "stack.json.gz"))
+
+		compressed, err := grpclog.LoadLog(filepath.Join(cacheDir, "grpc.json"))
+		require.NoError(t, err)
+		assert.NotEmpty(t, compressed.WhereMethod(grpclog.Create))
+	})
+}
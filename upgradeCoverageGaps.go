@@ -0,0 +1,186 @@
+package providertest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/providertest/providers"
+	"github.com/pulumi/providertest/pulumitest"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// ProviderSchemaTokens lists the resource and function tokens defined in a provider schema.
+type ProviderSchemaTokens struct {
+	Resources []string `json:"resources"`
+	Functions []string `json:"functions"`
+}
+
+// ParseProviderSchemaTokens reads the resource and function tokens from a JSON provider schema.
+func ParseProviderSchemaTokens(schemaBytes []byte) (*ProviderSchemaTokens, error) {
+	var schema struct {
+		Resources map[string]json.RawMessage `json:"resources"`
+		Functions map[string]json.RawMessage `json:"functions"`
+	}
+	if err := json.Unmarshal(schemaBytes, &schema); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal provider schema: %w", err)
+	}
+	tokens := &ProviderSchemaTokens{Resources: []string{}, Functions: []string{}}
+	for token := range schema.Resources {
+		tokens.Resources = append(tokens.Resources, token)
+	}
+	for token := range schema.Functions {
+		tokens.Functions = append(tokens.Functions, token)
+	}
+	sort.Strings(tokens.Resources)
+	sort.Strings(tokens.Functions)
+	return tokens, nil
+}
+
+// ReadProviderSchemaTokens reads the resource and function tokens from a JSON provider schema file.
+func ReadProviderSchemaTokens(path string) (*ProviderSchemaTokens, error) {
+	schemaBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseProviderSchemaTokens(schemaBytes)
+}
+
+// GetProviderSchemaTokens starts an in-process provider from the factory and reads the resource and function tokens
+// from the schema returned by its GetSchema method.
+func GetProviderSchemaTokens(t pulumitest.PT, factory providers.ResourceProviderServerFactory) *ProviderSchemaTokens {
+	t.Helper()
+	schemaBytes, err := getProviderSchema(pulumitest.TestContext(t), providers.ResourceProviderFactory(factory))
+	if err != nil {
+		t.Log(fmt.Sprintf("failed to get provider schema: %v", err))
+		t.FailNow()
+		return nil
+	}
+	tokens, err := ParseProviderSchemaTokens(schemaBytes)
+	if err != nil {
+		t.Log(err.Error())
+		t.FailNow()
+		return nil
+	}
+	return tokens
+}
+
+// getProviderSchema starts the provider from the factory and returns the JSON schema from its GetSchema method.
+// The provider is stopped before returning.
+func getProviderSchema(ctx context.Context, factory providers.ProviderFactory) ([]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	port, err := factory(ctx, noProgramProviderContext{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start provider: %w", err)
+	}
+	conn, err := grpc.NewClient(fmt.Sprintf("127.0.0.1:%d", port),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(1024*1024*400)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to provider: %w", err)
+	}
+	defer conn.Close()
+	resp, err := pulumirpc.NewResourceProviderClient(conn).GetSchema(ctx, &pulumirpc.GetSchemaRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return []byte(resp.GetSchema()), nil
+}
+
+// noProgramProviderContext is passed to provider factories when there's no program, such as when only the schema
+// is required.
+type noProgramProviderContext struct{}
+
+func (noProgramProviderContext) Source() string {
+	return ""
+}
+
+// UpgradeCoverageGaps describes the resources and functions in a provider schema which have no upgrade baseline.
+type UpgradeCoverageGaps struct {
+	UncoveredResources []string `json:"uncoveredResources"`
+	UncoveredFunctions []string `json:"uncoveredFunctions"`
+	// ResourceCoverage is the percentage of resources in the schema which are covered by at least one baseline.
+	ResourceCoverage float64 `json:"resourceCoverage"`
+	// FunctionCoverage is the percentage of functions in the schema which are invoked by at least one baseline.
+	FunctionCoverage float64 `json:"functionCoverage"`
+}
+
+// Gaps compares the report with the provider schema to find the resources and functions with no upgrade baseline.
+func (r *UpgradeCoverageReport) Gaps(schema *ProviderSchemaTokens) UpgradeCoverageGaps {
+	resources := r.ResourceCounts()
+	functions := r.FunctionCounts()
+	gaps := UpgradeCoverageGaps{
+		UncoveredResources: uncovered(schema.Resources, resources),
+		UncoveredFunctions: uncovered(schema.Functions, functions),
+	}
+	gaps.ResourceCoverage = coveragePercent(len(schema.Resources), len(gaps.UncoveredResources))
+	gaps.FunctionCoverage = coveragePercent(len(schema.Functions), len(gaps.UncoveredFunctions))
+	return gaps
+}
+
+func (g UpgradeCoverageGaps) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "resource coverage: %.1f%%, function coverage: %.1f%%", g.ResourceCoverage, g.FunctionCoverage)
+	if len(g.UncoveredResources) > 0 {
+		sb.WriteString("\nresources without an upgrade baseline:")
+		for _, token := range g.UncoveredResources {
+			sb.WriteString("\n- " + token)
+		}
+	}
+	if len(g.UncoveredFunctions) > 0 {
+		sb.WriteString("\nfunctions without an upgrade baseline:")
+		for _, token := range g.UncoveredFunctions {
+			sb.WriteString("\n- " + token)
+		}
+	}
+	return sb.String()
+}
+
+// UpgradeCoverageThreshold is the minimum percentage of resources and functions which must be covered by a baseline.
+type UpgradeCoverageThreshold struct {
+	Resources float64
+	Functions float64
+}
+
+// AssertUpgradeCoverage fails the test if the percentage of schema resources or functions covered by the report is
+// below the threshold. The uncovered resources and functions are always logged.
+func AssertUpgradeCoverage(t pulumitest.PT, report *UpgradeCoverageReport, schema *ProviderSchemaTokens, threshold UpgradeCoverageThreshold) UpgradeCoverageGaps {
+	t.Helper()
+	gaps := report.Gaps(schema)
+	t.Log(gaps.String())
+	if gaps.ResourceCoverage < threshold.Resources {
+		t.Log(fmt.Sprintf("resource upgrade coverage %.1f%% is below the threshold of %.1f%%",
+			gaps.ResourceCoverage, threshold.Resources))
+		t.Fail()
+	}
+	if gaps.FunctionCoverage < threshold.Functions {
+		t.Log(fmt.Sprintf("function upgrade coverage %.1f%% is below the threshold of %.1f%%",
+			gaps.FunctionCoverage, threshold.Functions))
+		t.Fail()
+	}
+	return gaps
+}
+
+func uncovered(tokens []string, covered map[string]int) []string {
+	result := []string{}
+	for _, token := range tokens {
+		if covered[token] == 0 {
+			result = append(result, token)
+		}
+	}
+	return result
+}
+
+// coveragePercent returns 100 when there is nothing to cover.
+func coveragePercent(total, uncovered int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return float64(total-uncovered) / float64(total) * 100
+}
@@ -0,0 +1,81 @@
+package providertest_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/providertest"
+	"github.com/pulumi/providertest/providers"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `{
+  "name": "random",
+  "resources": {
+    "random:index/randomPet:RandomPet": {},
+    "random:index/randomString:RandomString": {},
+    "random:index/randomId:RandomId": {}
+  },
+  "functions": {
+    "random:index/getThing:getThing": {}
+  }
+}`
+
+func TestUpgradeCoverageGaps(t *testing.T) {
+	t.Parallel()
+	schemaPath := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(testSchema), 0644))
+	schema, err := providertest.ReadProviderSchemaTokens(schemaPath)
+	require.NoError(t, err)
+
+	report := &providertest.UpgradeCoverageReport{
+		Programs: []providertest.ProgramUpgradeCoverage{{
+			ProgramName: "yaml_program",
+			Baselines: []providertest.BaselineUpgradeCoverage{{
+				BaselineVersion: "4.5.0",
+				Resources: map[string]int{
+					"random:index/randomPet:RandomPet":       1,
+					"random:index/randomString:RandomString": 2,
+				},
+			}},
+		}},
+	}
+
+	gaps := report.Gaps(schema)
+	assert.Equal(t, []string{"random:index/randomId:RandomId"}, gaps.UncoveredResources)
+	assert.Equal(t, []string{"random:index/getThing:getThing"}, gaps.UncoveredFunctions)
+	assert.InDelta(t, 66.7, gaps.ResourceCoverage, 0.1)
+	assert.Equal(t, 0.0, gaps.FunctionCoverage)
+
+	_, err = json.Marshal(gaps)
+	assert.NoError(t, err)
+
+	providertest.AssertUpgradeCoverage(t, report, schema, providertest.UpgradeCoverageThreshold{Resources: 50})
+}
+
+func TestGetProviderSchemaTokens(t *testing.T) {
+	t.Parallel()
+	factory := func(providers.PulumiTest) (pulumirpc.ResourceProviderServer, error) {
+		return providers.NewProviderMock(providers.ProviderMocks{
+			GetSchema: func(ctx context.Context, in *pulumirpc.GetSchemaRequest) (*pulumirpc.GetSchemaResponse, error) {
+				return &pulumirpc.GetSchemaResponse{Schema: testSchema}, nil
+			},
+		})
+	}
+
+	schema := providertest.GetProviderSchemaTokens(t, factory)
+
+	assert.Equal(t, &providertest.ProviderSchemaTokens{
+		Resources: []string{
+			"random:index/randomId:RandomId",
+			"random:index/randomPet:RandomPet",
+			"random:index/randomString:RandomString",
+		},
+		Functions: []string{"random:index/getThing:getThing"},
+	}, schema)
+}
@@ -0,0 +1,191 @@
+package providertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pulumi/providertest/pulumitest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+)
+
+// UpgradeExpectationsFile is the name of the file, stored alongside a cached baseline, which lists the changes
+// expected when upgrading from that baseline.
+const UpgradeExpectationsFile = "expectations.json"
+
+// UpgradeExpectations lists the changes which are allowed when upgrading from a baseline.
+type UpgradeExpectations struct {
+	Changes []ExpectedChange `json:"changes"`
+}
+
+// ExpectedChange allows changes to resources matching either a URN or a type.
+type ExpectedChange struct {
+	// URN matches a single resource. Takes precedence over Type if both are set.
+	URN string `json:"urn,omitempty"`
+	// Type matches all resources of the given type.
+	Type string `json:"type,omitempty"`
+	// Ops are the allowed operations. If empty, any operation is allowed.
+	Ops []apitype.OpType `json:"ops,omitempty"`
+	// Paths are the property paths which are allowed to change, including any nested properties.
+	// If empty, any property is allowed to change.
+	Paths []string `json:"paths,omitempty"`
+}
+
+// GetUpgradeExpectationsPath returns the path to the expectations file for a provider upgrade test.
+// If no cacheDirTemplatePath is provided, the default cache directory is used.
+func GetUpgradeExpectationsPath(programName, baselineVersion string, cacheDirTemplatePath ...string) string {
+	return filepath.Join(GetUpgradeCacheDir(programName, baselineVersion, cacheDirTemplatePath...), UpgradeExpectationsFile)
+}
+
+// ReadUpgradeExpectations reads the expectations from the given path.
+// If the file does not exist, returns empty expectations.
+func ReadUpgradeExpectations(path string) (*UpgradeExpectations, error) {
+	expectationsBytes, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &UpgradeExpectations{}, nil
+		}
+		return nil, err
+	}
+	var expectations UpgradeExpectations
+	if err := json.Unmarshal(expectationsBytes, &expectations); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal upgrade expectations at %s: %w", path, err)
+	}
+	return &expectations, nil
+}
+
+// WriteTo writes the expectations to the given path, creating any directories needed.
+func (e *UpgradeExpectations) WriteTo(path string) error {
+	expectationsBytes, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, expectationsBytes, 0644)
+}
+
+// ExpectationsFromReport creates expectations which allow exactly the changes in the report.
+func ExpectationsFromReport(report UpgradeReport) UpgradeExpectations {
+	expectations := UpgradeExpectations{Changes: []ExpectedChange{}}
+	for _, res := range report.Changed().Resources {
+		change := ExpectedChange{
+			URN: res.URN,
+			Ops: []apitype.OpType{res.Op},
+		}
+		for _, diff := range res.Diffs {
+			change.Paths = append(change.Paths, diff.Path)
+		}
+		expectations.Changes = append(expectations.Changes, change)
+	}
+	return expectations
+}
+
+// Unexpected returns a report of the changes not covered by the expectations.
+// Resources are only included with the property diffs which were not expected.
+func (e *UpgradeExpectations) Unexpected(report UpgradeReport) UpgradeReport {
+	unexpected := UpgradeReport{Resources: []ResourceUpgrade{}}
+	for _, res := range report.Changed().Resources {
+		var allowed []ExpectedChange
+		for _, change := range e.Changes {
+			if change.matches(res) {
+				allowed = append(allowed, change)
+			}
+		}
+		if len(allowed) == 0 {
+			unexpected.Resources = append(unexpected.Resources, res)
+			continue
+		}
+		var unexpectedDiffs []PropertyUpgradeDiff
+		for _, diff := range res.Diffs {
+			if !anyAllowsPath(allowed, diff.Path) {
+				unexpectedDiffs = append(unexpectedDiffs, diff)
+			}
+		}
+		if len(unexpectedDiffs) > 0 {
+			res.Diffs = unexpectedDiffs
+			unexpected.Resources = append(unexpected.Resources, res)
+		}
+	}
+	return unexpected
+}
+
+func (c ExpectedChange) matches(res ResourceUpgrade) bool {
+	switch {
+	case c.URN != "":
+		if c.URN != res.URN {
+			return false
+		}
+	case c.Type != "":
+		if c.Type != res.Type {
+			return false
+		}
+	default:
+		return false
+	}
+	if len(c.Ops) == 0 {
+		return true
+	}
+	for _, op := range c.Ops {
+		if op == res.Op {
+			return true
+		}
+	}
+	return false
+}
+
+func anyAllowsPath(changes []ExpectedChange, path string) bool {
+	for _, change := range changes {
+		if len(change.Paths) == 0 {
+			return true
+		}
+		for _, allowed := range change.Paths {
+			if path == allowed || strings.HasPrefix(path, allowed+".") || strings.HasPrefix(path, allowed+"[") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// updateUpgradeExpectations returns true if the PULUMITEST_UPDATE_UPGRADE_EXPECTATIONS environment variable is set
+// to "true".
+func updateUpgradeExpectations() bool {
+	value, ok := os.LookupEnv("PULUMITEST_UPDATE_UPGRADE_EXPECTATIONS")
+	return ok && strings.EqualFold(value, "true")
+}
+
+// AssertUpgradeExpectations fails the test if the report contains changes not allowed by the expectations file at
+// the given path. A missing expectations file allows no changes.
+// When the PULUMITEST_UPDATE_UPGRADE_EXPECTATIONS environment variable is set to "true", the expectations file is
+// instead overwritten with the changes in the report so they can be reviewed.
+func AssertUpgradeExpectations(t pulumitest.PT, report UpgradeReport, expectationsPath string) {
+	t.Helper()
+	if updateUpgradeExpectations() {
+		expectations := ExpectationsFromReport(report)
+		if err := expectations.WriteTo(expectationsPath); err != nil {
+			t.Log(fmt.Sprintf("failed to write upgrade expectations: %v", err))
+			t.FailNow()
+			return
+		}
+		t.Log(fmt.Sprintf("wrote upgrade expectations to %s", expectationsPath))
+		return
+	}
+
+	expectations, err := ReadUpgradeExpectations(expectationsPath)
+	if err != nil {
+		t.Log(fmt.Sprintf("failed to read upgrade expectations: %v", err))
+		t.FailNow()
+		return
+	}
+	unexpected := expectations.Unexpected(report)
+	if len(unexpected.Resources) > 0 {
+		t.Log(fmt.Sprintf("unexpected changes not listed in %s:\n%s\n"+
+			"To accept these changes, set PULUMITEST_UPDATE_UPGRADE_EXPECTATIONS=true and review the updated file",
+			expectationsPath, unexpected))
+		t.Fail()
+	}
+}
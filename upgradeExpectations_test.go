@@ -0,0 +1,84 @@
+package providertest_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/providertest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpgradeExpectations(t *testing.T) {
+	t.Parallel()
+	const bucketURN = "urn:pulumi:test::prog::aws:s3/bucket:Bucket::bucket"
+	const queueURN = "urn:pulumi:test::prog::aws:sqs/queue:Queue::queue"
+	const topicURN = "urn:pulumi:test::prog::aws:sns/topic:Topic::topic"
+
+	report := providertest.UpgradeReport{Resources: []providertest.ResourceUpgrade{
+		{
+			URN: bucketURN, Type: "aws:s3/bucket:Bucket", Op: apitype.OpUpdate,
+			Diffs: []providertest.PropertyUpgradeDiff{
+				{Path: "tags.Name", Kind: apitype.DiffAdd},
+				{Path: "tagsAll", Kind: apitype.DiffUpdate},
+			},
+		},
+		{URN: queueURN, Type: "aws:sqs/queue:Queue", Op: apitype.OpSame},
+		{
+			URN: topicURN, Type: "aws:sns/topic:Topic", Op: apitype.OpReplace,
+			Diffs: []providertest.PropertyUpgradeDiff{
+				{Path: "name", Kind: apitype.DiffUpdateReplace},
+			},
+		},
+	}}
+
+	t.Run("no expectations", func(t *testing.T) {
+		expectations := providertest.UpgradeExpectations{}
+		unexpected := expectations.Unexpected(report)
+		require.Len(t, unexpected.Resources, 2)
+		assert.Equal(t, bucketURN, unexpected.Resources[0].URN)
+		assert.Equal(t, topicURN, unexpected.Resources[1].URN)
+	})
+
+	t.Run("allowed paths", func(t *testing.T) {
+		expectations := providertest.UpgradeExpectations{Changes: []providertest.ExpectedChange{
+			{Type: "aws:s3/bucket:Bucket", Ops: []apitype.OpType{apitype.OpUpdate}, Paths: []string{"tags"}},
+			{URN: topicURN},
+		}}
+		unexpected := expectations.Unexpected(report)
+		require.Len(t, unexpected.Resources, 1)
+		assert.Equal(t, bucketURN, unexpected.Resources[0].URN)
+		assert.Equal(t, []providertest.PropertyUpgradeDiff{{Path: "tagsAll", Kind: apitype.DiffUpdate}},
+			unexpected.Resources[0].Diffs)
+	})
+
+	t.Run("disallowed op", func(t *testing.T) {
+		expectations := providertest.UpgradeExpectations{Changes: []providertest.ExpectedChange{
+			{Type: "aws:s3/bucket:Bucket"},
+			{URN: topicURN, Ops: []apitype.OpType{apitype.OpUpdate}, Paths: []string{"name"}},
+		}}
+		unexpected := expectations.Unexpected(report)
+		require.Len(t, unexpected.Resources, 1)
+		assert.Equal(t, topicURN, unexpected.Resources[0].URN)
+	})
+
+	t.Run("round trip from report", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "baseline", providertest.UpgradeExpectationsFile)
+		fromReport := providertest.ExpectationsFromReport(report)
+		require.NoError(t, fromReport.WriteTo(path))
+
+		expectations, err := providertest.ReadUpgradeExpectations(path)
+		require.NoError(t, err)
+		assert.Len(t, expectations.Changes, 2)
+		assert.Empty(t, expectations.Unexpected(report).Resources)
+
+		providertest.AssertUpgradeExpectations(t, report, path)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		expectations, err := providertest.ReadUpgradeExpectations(filepath.Join(t.TempDir(), "missing.json"))
+		require.NoError(t, err)
+		assert.Empty(t, expectations.Changes)
+	})
+}
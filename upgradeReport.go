@@ -0,0 +1,212 @@
+package providertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/providertest/optproviderupgrade"
+	"github.com/pulumi/providertest/pulumitest"
+	"github.com/pulumi/providertest/pulumitest/optnewstack"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optpreview"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// UpgradeReport lists the operation and property changes for every resource in an upgrade preview.
+type UpgradeReport struct {
+	// Resources are sorted by URN.
+	Resources []ResourceUpgrade `json:"resources"`
+}
+
+// ResourceUpgrade describes the operation performed on a single resource and the properties which changed.
+type ResourceUpgrade struct {
+	URN  string         `json:"urn"`
+	Type string         `json:"type"`
+	Op   apitype.OpType `json:"op"`
+	// Diffs are sorted by property path.
+	Diffs []PropertyUpgradeDiff `json:"diffs,omitempty"`
+}
+
+// PropertyUpgradeDiff describes the change to a single property.
+type PropertyUpgradeDiff struct {
+	// Path is the property path such as "tags.Name" or "rules[0].port".
+	Path string           `json:"path"`
+	Kind apitype.DiffKind `json:"kind"`
+	// InputDiff is true if the difference is between old and new inputs rather than old state and new inputs.
+	InputDiff bool `json:"inputDiff,omitempty"`
+	Old       any  `json:"old,omitempty"`
+	New       any  `json:"new,omitempty"`
+}
+
+// PreviewProviderUpgradeWithReport is the same as PreviewProviderUpgrade but also captures the engine events from the
+// preview to build a report of the operation and property diffs for every resource.
+func PreviewProviderUpgradeWithReport(t pulumitest.PT, pulumiTest *pulumitest.PulumiTest, providerName string, baselineVersion string, opts ...optproviderupgrade.PreviewProviderUpgradeOpt) (auto.PreviewResult, UpgradeReport) {
+	t.Helper()
+	options := optproviderupgrade.Defaults()
+	for _, opt := range opts {
+		opt.Apply(&options)
+	}
+	previewTest := prepareUpgradeTest(t, pulumiTest, providerName, baselineVersion, options, optnewstack.DisableAutoDestroy())
+
+	result := previewTest.Preview(t, optpreview.Diff())
+	return result, NewUpgradeReport(previewTest.Events())
+}
+
+// NewUpgradeReport builds an upgrade report from the resource pre-events emitted by the engine.
+// Where a resource is replaced, only the "replace" step is reported rather than each of the individual
+// create-replacement and delete-replaced steps.
+func NewUpgradeReport(engineEvents []events.EngineEvent) UpgradeReport {
+	byURN := map[string]ResourceUpgrade{}
+	for _, e := range engineEvents {
+		if e.ResourcePreEvent == nil {
+			continue
+		}
+		metadata := e.ResourcePreEvent.Metadata
+		if existing, ok := byURN[metadata.URN]; ok && (existing.Op == apitype.OpReplace || metadata.Op != apitype.OpReplace) {
+			continue
+		}
+		byURN[metadata.URN] = ResourceUpgrade{
+			URN:   metadata.URN,
+			Type:  metadata.Type,
+			Op:    metadata.Op,
+			Diffs: propertyDiffs(metadata),
+		}
+	}
+
+	report := UpgradeReport{Resources: []ResourceUpgrade{}}
+	for _, r := range byURN {
+		report.Resources = append(report.Resources, r)
+	}
+	sort.Slice(report.Resources, func(i, j int) bool {
+		return report.Resources[i].URN < report.Resources[j].URN
+	})
+	return report
+}
+
+func propertyDiffs(metadata apitype.StepEventMetadata) []PropertyUpgradeDiff {
+	var oldInputs, oldOutputs, newInputs, newOutputs map[string]any
+	if metadata.Old != nil {
+		oldInputs, oldOutputs = metadata.Old.Inputs, metadata.Old.Outputs
+	}
+	if metadata.New != nil {
+		newInputs, newOutputs = metadata.New.Inputs, metadata.New.Outputs
+	}
+
+	var diffs []PropertyUpgradeDiff
+	if len(metadata.DetailedDiff) > 0 {
+		for path, diff := range metadata.DetailedDiff {
+			oldValues := oldOutputs
+			if diff.InputDiff {
+				oldValues = oldInputs
+			}
+			diffs = append(diffs, PropertyUpgradeDiff{
+				Path:      path,
+				Kind:      diff.Kind,
+				InputDiff: diff.InputDiff,
+				Old:       lookupPropertyPath(path, oldValues),
+				New:       lookupPropertyPath(path, newInputs, newOutputs),
+			})
+		}
+	} else {
+		// The provider didn't return a detailed diff, so fall back to the top-level keys which changed.
+		for _, key := range metadata.Diffs {
+			diffs = append(diffs, PropertyUpgradeDiff{
+				Path: key,
+				Kind: apitype.DiffUpdate,
+				Old:  lookupPropertyPath(key, oldOutputs, oldInputs),
+				New:  lookupPropertyPath(key, newInputs, newOutputs),
+			})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Path < diffs[j].Path
+	})
+	return diffs
+}
+
+// lookupPropertyPath returns the value at the property path in the first of the property maps which contains it,
+// or nil if not found.
+func lookupPropertyPath(path string, propertyMaps ...map[string]any) any {
+	propertyPath, err := resource.ParsePropertyPath(path)
+	if err != nil {
+		return nil
+	}
+	for _, properties := range propertyMaps {
+		if properties == nil {
+			continue
+		}
+		value, ok := propertyPath.Get(resource.NewObjectProperty(resource.NewPropertyMapFromMap(properties)))
+		if ok {
+			return value.Mappable()
+		}
+	}
+	return nil
+}
+
+// Changed returns a report containing only the resources whose operation is not "same".
+func (r UpgradeReport) Changed() UpgradeReport {
+	changed := UpgradeReport{Resources: []ResourceUpgrade{}}
+	for _, res := range r.Resources {
+		if res.Op != apitype.OpSame {
+			changed.Resources = append(changed.Resources, res)
+		}
+	}
+	return changed
+}
+
+// String renders the changed resources and their property diffs for test output.
+func (r UpgradeReport) String() string {
+	changed := r.Changed()
+	if len(changed.Resources) == 0 {
+		return "no changes"
+	}
+	var sb strings.Builder
+	for i, res := range changed.Resources {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(res.String())
+	}
+	return sb.String()
+}
+
+func (r ResourceUpgrade) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s (%s)", r.URN, r.Op)
+	for _, diff := range r.Diffs {
+		sb.WriteString("\n  " + diff.String())
+	}
+	return sb.String()
+}
+
+func (d PropertyUpgradeDiff) String() string {
+	var description string
+	switch d.Kind {
+	case apitype.DiffAdd, apitype.DiffAddReplace:
+		description = fmt.Sprintf("+ %s: %s", d.Path, formatPropertyValue(d.New))
+	case apitype.DiffDelete, apitype.DiffDeleteReplace:
+		description = fmt.Sprintf("- %s: %s", d.Path, formatPropertyValue(d.Old))
+	default:
+		description = fmt.Sprintf("~ %s: %s => %s", d.Path, formatPropertyValue(d.Old), formatPropertyValue(d.New))
+	}
+	switch d.Kind {
+	case apitype.DiffAddReplace, apitype.DiffDeleteReplace, apitype.DiffUpdateReplace:
+		description += " (forces replacement)"
+	}
+	return description
+}
+
+func formatPropertyValue(value any) string {
+	if value == nil {
+		return "<nil>"
+	}
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(valueBytes)
+}
@@ -0,0 +1,104 @@
+package providertest_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/providertest"
+	"github.com/pulumi/providertest/optproviderupgrade"
+	"github.com/pulumi/providertest/pulumitest"
+	"github.com/pulumi/providertest/pulumitest/opttest"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewUpgradeWithReport(t *testing.T) {
+	t.Parallel()
+	cacheDir := t.TempDir()
+	test := pulumitest.NewPulumiTest(t, filepath.Join("pulumitest", "testdata", "yaml_program"),
+		opttest.DownloadProviderVersion("random", "4.15.0"))
+
+	_, report := providertest.PreviewProviderUpgradeWithReport(t, test, "random", "4.5.0",
+		optproviderupgrade.CacheDir(cacheDir, "{programName}", "{baselineVersion}"),
+		optproviderupgrade.DisableAttach(),
+		optproviderupgrade.NewSourcePath(filepath.Join("pulumitest", "testdata", "yaml_program_updated")),
+	)
+
+	changed := report.Changed()
+	require.Len(t, changed.Resources, 1, report.String())
+	assert.Equal(t, apitype.OpCreate, changed.Resources[0].Op)
+	assert.Equal(t, "random:index/randomPassword:RandomPassword", changed.Resources[0].Type)
+}
+
+func TestNewUpgradeReport(t *testing.T) {
+	t.Parallel()
+	const bucketURN = "urn:pulumi:test::prog::aws:s3/bucket:Bucket::bucket"
+	const queueURN = "urn:pulumi:test::prog::aws:sqs/queue:Queue::queue"
+	const topicURN = "urn:pulumi:test::prog::aws:sns/topic:Topic::topic"
+	step := func(urn string, op apitype.OpType, metadata apitype.StepEventMetadata) events.EngineEvent {
+		metadata.URN = urn
+		metadata.Op = op
+		return events.EngineEvent{EngineEvent: apitype.EngineEvent{
+			ResourcePreEvent: &apitype.ResourcePreEvent{Metadata: metadata},
+		}}
+	}
+	engineEvents := []events.EngineEvent{
+		{EngineEvent: apitype.EngineEvent{PreludeEvent: &apitype.PreludeEvent{}}},
+		step(topicURN, apitype.OpSame, apitype.StepEventMetadata{Type: "aws:sns/topic:Topic"}),
+		step(bucketURN, apitype.OpUpdate, apitype.StepEventMetadata{
+			Type: "aws:s3/bucket:Bucket",
+			Old: &apitype.StepEventStateMetadata{
+				Inputs:  map[string]any{"tags": map[string]any{"Name": "old"}},
+				Outputs: map[string]any{"tags": map[string]any{"Name": "old"}, "region": "us-east-1"},
+			},
+			New: &apitype.StepEventStateMetadata{
+				Inputs: map[string]any{"tags": map[string]any{"Name": "new"}, "acl": "private"},
+			},
+			DetailedDiff: map[string]apitype.PropertyDiff{
+				"tags.Name": {Kind: apitype.DiffUpdate, InputDiff: true},
+				"acl":       {Kind: apitype.DiffAdd},
+				"region":    {Kind: apitype.DiffDelete},
+			},
+		}),
+		step(queueURN, apitype.OpCreateReplacement, apitype.StepEventMetadata{Type: "aws:sqs/queue:Queue"}),
+		step(queueURN, apitype.OpReplace, apitype.StepEventMetadata{
+			Type:  "aws:sqs/queue:Queue",
+			Old:   &apitype.StepEventStateMetadata{Outputs: map[string]any{"fifo": false}},
+			New:   &apitype.StepEventStateMetadata{Inputs: map[string]any{"fifo": true}},
+			Diffs: []string{"fifo"},
+		}),
+		step(queueURN, apitype.OpDeleteReplaced, apitype.StepEventMetadata{Type: "aws:sqs/queue:Queue"}),
+	}
+
+	report := providertest.NewUpgradeReport(engineEvents)
+
+	assert.Equal(t, providertest.UpgradeReport{Resources: []providertest.ResourceUpgrade{
+		{URN: bucketURN, Type: "aws:s3/bucket:Bucket", Op: apitype.OpUpdate, Diffs: []providertest.PropertyUpgradeDiff{
+			{Path: "acl", Kind: apitype.DiffAdd, New: "private"},
+			{Path: "region", Kind: apitype.DiffDelete, Old: "us-east-1"},
+			{Path: "tags.Name", Kind: apitype.DiffUpdate, InputDiff: true, Old: "old", New: "new"},
+		}},
+		{URN: topicURN, Type: "aws:sns/topic:Topic", Op: apitype.OpSame},
+		{URN: queueURN, Type: "aws:sqs/queue:Queue", Op: apitype.OpReplace, Diffs: []providertest.PropertyUpgradeDiff{
+			{Path: "fifo", Kind: apitype.DiffUpdate, Old: false, New: true},
+		}},
+	}}, report)
+
+	assert.Len(t, report.Changed().Resources, 2)
+	assert.Equal(t, bucketURN+" (update)\n"+
+		"  + acl: \"private\"\n"+
+		"  - region: \"us-east-1\"\n"+
+		"  ~ tags.Name: \"old\" => \"new\"\n"+
+		queueURN+" (replace)\n"+
+		"  ~ fifo: false => true",
+		report.String())
+
+	reportBytes, err := json.Marshal(report)
+	require.NoError(t, err)
+	var roundTripped providertest.UpgradeReport
+	require.NoError(t, json.Unmarshal(reportBytes, &roundTripped))
+	assert.Equal(t, report, roundTripped)
+}